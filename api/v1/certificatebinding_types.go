@@ -38,17 +38,42 @@ type DestinationConfig struct {
 	KeyVaultName string `json:"keyVaultName,omitempty"`
 
 	// CertificateName is the name to use for the certificate in the destination.
+	// For AWSSecretsManager type it is the name or ARN of the secret, for GCPSecretManager type the
+	// secret ID and for GCPCertificateManager type the certificate ID. For Cloudflare type it is the ID of
+	// the custom certificate checked and deleted on cleanup, as recorded in the destination status.
 	// +optional
 	CertificateName string `json:"certificateName,omitempty"`
 
 	// CertificateARN is the ARN of the ACM certificate (for AWSACM type).
+	// Defaults to the ARN recorded from the first import.
 	// +optional
 	CertificateARN string `json:"certificateArn,omitempty"`
 
-	// Region is the AWS region (for AWSACM type).
+	// Listeners lists Application and Network Load Balancer listeners that serve the
+	// imported certificate (for AWSACM type). When an import creates a new certificate,
+	// the certificate previously imported from the same source secret is removed from them.
+	// +optional
+	Listeners []LoadBalancerListener `json:"listeners,omitempty"`
+
+	// Region is the AWS region (for AWSACM, AWSSecretsManager, AWSIAMServerCertificate and ObjectStorage types).
 	// +optional
 	Region string `json:"region,omitempty"`
 
+	// KMSKeyID is the ID, ARN or alias of the KMS key used to encrypt the secret (for AWSSecretsManager type)
+	// or the objects with SSE-KMS (for ObjectStorage type). Defaults to the aws/secretsmanager key, and to
+	// the default encryption of the bucket for ObjectStorage.
+	// +optional
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+
+	// SecretFields names the JSON fields of the secret value (for AWSSecretsManager type).
+	// +optional
+	SecretFields *SecretFields `json:"secretFields,omitempty"`
+
+	// Tags are set on the secret or objects in addition to ManagedBy=certauto (for AWSSecretsManager and
+	// ObjectStorage types).
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// TargetNamespace is the target namespace (for Kubernetes type).
 	// +optional
 	TargetNamespace string `json:"targetNamespace,omitempty"`
@@ -56,6 +81,689 @@ type DestinationConfig struct {
 	// TargetSecretName is the target secret name (for Kubernetes type).
 	// +optional
 	TargetSecretName string `json:"targetSecretName,omitempty"`
+
+	// TargetNamespaces is a list of additional target namespaces (for ConfigMap type).
+	// +optional
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+
+	// ConfigMapName is the name of the target ConfigMap (for ConfigMap type).
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// BundleKey is the ConfigMap key holding the PEM encoded CA bundle (for ConfigMap type).
+	// Defaults to ca.crt.
+	// +optional
+	BundleKey string `json:"bundleKey,omitempty"`
+
+	// RemoteCluster defines the cluster to reflect the secret into (for RemoteKubernetes type).
+	// The target secret is configured with the same fields as the Kubernetes type.
+	// +optional
+	RemoteCluster *RemoteCluster `json:"remoteCluster,omitempty"`
+
+	// ClusterSelector selects Cluster API workload clusters to reflect the secret into (for RemoteKubernetes type).
+	// Each matching cluster is reached through its <name>-kubeconfig secret. Mutually exclusive with remoteCluster.
+	// +optional
+	ClusterSelector *ClusterSelector `json:"clusterSelector,omitempty"`
+
+	// Gateway references the Gateway API listener that serves the certificate (for GatewayAPI type).
+	// The secret is reflected into targetNamespace, which defaults to the Gateway namespace, and
+	// referenced from the listener. A ReferenceGrant is created when the namespaces differ.
+	// Both namespaces must be the namespace of the binding unless the config comes from a
+	// DestinationProvider.
+	// +optional
+	Gateway *GatewayListenerRef `json:"gateway,omitempty"`
+
+	// GCP defines the Google Cloud project and credentials (for GCPSecretManager and GCPCertificateManager types).
+	// +optional
+	GCP *GCPProject `json:"gcp,omitempty"`
+
+	// Labels are set on the Google Cloud resource in addition to managed-by=certauto
+	// (for GCPSecretManager and GCPCertificateManager types).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// PreviousVersions controls what happens to secret versions older than the latest one
+	// (for GCPSecretManager type). Defaults to Keep.
+	// +kubebuilder:validation:Enum=Keep;Disable;Destroy
+	// +optional
+	PreviousVersions string `json:"previousVersions,omitempty"`
+
+	// Location is the Certificate Manager location, global or a region (for GCPCertificateManager type).
+	// Defaults to global.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// CertificateMapEntries lists certificate map entries that serve the certificate (for GCPCertificateManager type).
+	// Certificate maps only accept global certificates. Missing entries are created.
+	// +optional
+	CertificateMapEntries []CertificateMapEntryRef `json:"certificateMapEntries,omitempty"`
+
+	// IAMServerCertificate defines the IAM server certificate to upload and the load
+	// balancers and CloudFront distributions that use it (for AWSIAMServerCertificate type).
+	// +optional
+	IAMServerCertificate *IAMServerCertificate `json:"iamServerCertificate,omitempty"`
+
+	// Vault defines the Vault KV secrets engine path to write the certificate to (for VaultKV type).
+	// +optional
+	Vault *VaultKV `json:"vault,omitempty"`
+
+	// Cloudflare defines the zone to upload the certificate to as a custom certificate (for Cloudflare type).
+	// +optional
+	Cloudflare *CloudflareZone `json:"cloudflare,omitempty"`
+
+	// Webhook defines the HTTP endpoint the certificate is sent to (for Webhook type).
+	// +optional
+	Webhook *Webhook `json:"webhook,omitempty"`
+
+	// SSH defines the remote host and path the certificate files are written to over SFTP (for SSH type).
+	// +optional
+	SSH *SSHTarget `json:"ssh,omitempty"`
+
+	// ObjectStorage defines the S3-compatible bucket the certificate objects are written to (for ObjectStorage type).
+	// +optional
+	ObjectStorage *ObjectStorage `json:"objectStorage,omitempty"`
+
+	// InjectionTargets lists the resources whose caBundle is set to the CA of the certificate (for CAInjection type).
+	// Each resource must opt in with the certauto.sanorg.in/inject-ca-from-secret annotation set to the
+	// <namespace>/<name> of the source secret.
+	// +optional
+	InjectionTargets []CAInjectionTarget `json:"injectionTargets,omitempty"`
+
+	// SecretTemplate defines labels and annotations to set on the target secret (for Kubernetes and ConfigMap types).
+	// Values here take precedence over the binding-level secretTemplate. Labels and annotations
+	// added to the target by other tools are kept.
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+
+	// OutputFormats defines additional keys to write to the target secret, each
+	// holding the certificate in a different format (for Kubernetes and ConfigMap types).
+	// For ObjectStorage type each key is written as an additional object.
+	// ConfigMap destinations only support formats without the private key.
+	// +optional
+	OutputFormats []OutputFormat `json:"outputFormats,omitempty"`
+
+	// DataTemplates maps target secret keys to Go templates rendered over the
+	// parsed certificate material (for Kubernetes type). Available fields are
+	// .Certificate, .Leaf, .Chain, .FullChain, .CA, .PrivateKey, .PrivateKeyPKCS8,
+	// .CommonName, .DNSNames, .SerialNumber, .Fingerprint, .NotBefore and .NotAfter.
+	// The private key fields are empty when includePrivateKey is false. .PrivateKey
+	// is encoded as set by privateKey, and both private key fields hold the
+	// encrypted key when a passphrase is configured.
+	// +optional
+	DataTemplates map[string]string `json:"dataTemplates,omitempty"`
+
+	// SecretType is the type of the target secret (for Kubernetes type). Defaults to kubernetes.io/tls,
+	// or Opaque when includePrivateKey is false.
+	// Opaque secrets only carry the tls.crt, tls.key and ca.crt keys when no dataTemplates are set.
+	// +kubebuilder:validation:Enum=kubernetes.io/tls;Opaque
+	// +optional
+	SecretType string `json:"secretType,omitempty"`
+
+	// IncludePrivateKey controls whether the private key is distributed (for Kubernetes, Webhook and ObjectStorage types).
+	// When false only the certificate and CA are written. Defaults to true.
+	// +optional
+	IncludePrivateKey *bool `json:"includePrivateKey,omitempty"`
+
+	// TargetKind is the kind of object written to the target namespace (for Kubernetes type).
+	// ConfigMap requires includePrivateKey to be false and replaces a secret of the same name
+	// written by certauto. Defaults to Secret.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +optional
+	TargetKind string `json:"targetKind,omitempty"`
+
+	// Versioned writes immutable secrets named <targetSecretName>-<hash> instead of updating
+	// the target secret in place (for Kubernetes type). The target secret becomes a pointer
+	// to the current generation.
+	// +optional
+	Versioned *VersionedSecrets `json:"versioned,omitempty"`
+
+	// RestartTargets lists workloads to restart after the certificate in this destination
+	// changes (for Kubernetes type). The namespace defaults to targetNamespace.
+	// +optional
+	RestartTargets *RestartTargets `json:"restartTargets,omitempty"`
+
+	// PrivateKey defines how the private key is encoded before it is written (for all types).
+	// +optional
+	PrivateKey *PrivateKeyOptions `json:"privateKey,omitempty"`
+
+	// DeletionPolicy defines what happens to what was synced to the destination when its
+	// rule is removed or the binding is deleted (for all types). Defaults to Retain.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// DeletionPolicy defines what happens to a destination that is no longer synced.
+// +kubebuilder:validation:Enum=Retain;Delete
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain leaves what was synced in the destination.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyDelete deletes what was synced from the destination.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// PrivateKeyEncoding is the encoding used for a private key.
+// +kubebuilder:validation:Enum=PKCS1;PKCS8;SEC1
+type PrivateKeyEncoding string
+
+const (
+	// PrivateKeyEncodingPKCS1 encodes RSA keys as PKCS#1.
+	PrivateKeyEncodingPKCS1 PrivateKeyEncoding = "PKCS1"
+	// PrivateKeyEncodingPKCS8 encodes keys as PKCS#8.
+	PrivateKeyEncodingPKCS8 PrivateKeyEncoding = "PKCS8"
+	// PrivateKeyEncodingSEC1 encodes ECDSA keys as SEC 1.
+	PrivateKeyEncodingSEC1 PrivateKeyEncoding = "SEC1"
+)
+
+// PrivateKeyOptions defines how the private key is re-encoded for a destination.
+type PrivateKeyOptions struct {
+	// Encoding of the private key. Defaults to the encoding of the source secret,
+	// or PKCS8 when a passphrase is set.
+	// +optional
+	Encoding PrivateKeyEncoding `json:"encoding,omitempty"`
+
+	// PassphraseSecretRef references a passphrase used to encrypt the key as PKCS#8.
+	// Only supported with PKCS8 encoding. Kubernetes destinations require secretType Opaque,
+	// as consumers of kubernetes.io/tls secrets expect an unencrypted key.
+	// +optional
+	PassphraseSecretRef *SecretKeyRef `json:"passphraseSecretRef,omitempty"`
+}
+
+// OutputFormatType is the encoding used for an additional target secret key.
+// +kubebuilder:validation:Enum=PKCS12;PKCS12Truststore;JKS;JKSTruststore;DER;CombinedPEM;FullChain
+type OutputFormatType string
+
+const (
+	// OutputFormatPKCS12 is a PKCS#12 keystore holding the private key and certificate chain.
+	OutputFormatPKCS12 OutputFormatType = "PKCS12"
+	// OutputFormatPKCS12Truststore is a PKCS#12 keystore holding only the CA certificates.
+	OutputFormatPKCS12Truststore OutputFormatType = "PKCS12Truststore"
+	// OutputFormatJKS is a Java keystore holding the private key and certificate chain.
+	OutputFormatJKS OutputFormatType = "JKS"
+	// OutputFormatJKSTruststore is a Java keystore holding only the CA certificates.
+	OutputFormatJKSTruststore OutputFormatType = "JKSTruststore"
+	// OutputFormatDER is the leaf certificate in DER encoding.
+	OutputFormatDER OutputFormatType = "DER"
+	// OutputFormatCombinedPEM is the private key followed by the full chain, as used by HAProxy.
+	OutputFormatCombinedPEM OutputFormatType = "CombinedPEM"
+	// OutputFormatFullChain is the certificate chain including the CA certificates.
+	OutputFormatFullChain OutputFormatType = "FullChain"
+)
+
+// OutputFormat defines an additional key in the target secret and its format.
+type OutputFormat struct {
+	// Key is the name of the key in the target secret (e.g. keystore.p12).
+	Key string `json:"key"`
+
+	// Format is the encoding of the value.
+	Format OutputFormatType `json:"format"`
+
+	// PasswordSecretRef references the keystore password (for PKCS12, PKCS12Truststore, JKS and JKSTruststore formats).
+	// Required for JKS formats.
+	// +optional
+	PasswordSecretRef *SecretKeyRef `json:"passwordSecretRef,omitempty"`
+}
+
+// SecretFields names the fields of a JSON secret holding a certificate.
+type SecretFields struct {
+	// Certificate is the field holding the PEM encoded leaf certificate. Defaults to certificate.
+	// +optional
+	Certificate string `json:"certificate,omitempty"`
+
+	// PrivateKey is the field holding the PEM encoded private key. Defaults to private_key.
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty"`
+
+	// Chain is the field holding the PEM encoded intermediate and CA certificates. Defaults to certificate_chain.
+	// +optional
+	Chain string `json:"chain,omitempty"`
+}
+
+// SecretKeyRef references a key within a Kubernetes secret.
+type SecretKeyRef struct {
+	// Name of the secret.
+	Name string `json:"name"`
+	// Namespace of the secret. Defaults to the namespace of the binding. Only DestinationProvider
+	// configs may reference secrets in other namespaces.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Key within the secret data.
+	Key string `json:"key"`
+}
+
+// RemoteCluster defines how to connect to a remote Kubernetes cluster. Either
+// kubeconfigSecretRef or server and tokenSecretRef must be set.
+type RemoteCluster struct {
+	// KubeconfigSecretRef references a kubeconfig for the remote cluster.
+	// Credentials must be inline; file paths are rejected. Exec and auth
+	// provider plugins may only be used by DestinationProvider configs.
+	// +optional
+	KubeconfigSecretRef *SecretKeyRef `json:"kubeconfigSecretRef,omitempty"`
+
+	// Server is the URL of the remote API server, used with tokenSecretRef.
+	// +optional
+	Server string `json:"server,omitempty"`
+
+	// TokenSecretRef references a bearer token for the remote API server.
+	// +optional
+	TokenSecretRef *SecretKeyRef `json:"tokenSecretRef,omitempty"`
+
+	// CASecretRef references the PEM encoded CA of the remote API server, used with tokenSecretRef.
+	// +optional
+	CASecretRef *SecretKeyRef `json:"caSecretRef,omitempty"`
+}
+
+// ClusterSelector selects Cluster API Cluster objects.
+type ClusterSelector struct {
+	// Namespace limits the selection to Cluster objects in this namespace. Defaults to the namespace
+	// of the binding. Only DestinationProvider configs may select clusters in other namespaces.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// LabelSelector selects Cluster objects by label. An empty selector matches every cluster.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// VersionedSecrets configures content-hash versioned target secrets.
+type VersionedSecrets struct {
+	// GracePeriod is how long superseded generations are kept before they are deleted.
+	// Generations still referenced by a pod in the target namespace are kept until the
+	// pod is gone. Defaults to 24h.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// RestartTargets selects workloads whose pods are restarted when the certificate
+// changes, for applications that only read TLS material at start-up. Pods are
+// restarted by setting the certauto.sanorg.in/restartedAt pod template annotation.
+type RestartTargets struct {
+	// Namespace of the workloads. Must be the namespace of the binding or, for
+	// destination restart targets, the target namespace of the destination.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Workloads lists workloads by kind and name.
+	// +optional
+	Workloads []WorkloadRef `json:"workloads,omitempty"`
+
+	// Selector selects Deployments, StatefulSets and DaemonSets by label.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// WorkloadRef references a workload to restart.
+type WorkloadRef struct {
+	// Kind of the workload.
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
+	Kind string `json:"kind"`
+
+	// Name of the workload.
+	Name string `json:"name"`
+}
+
+// GatewayListenerRef references a listener of a Gateway API Gateway.
+type GatewayListenerRef struct {
+	// Name of the Gateway.
+	Name string `json:"name"`
+
+	// Namespace of the Gateway.
+	Namespace string `json:"namespace"`
+
+	// ListenerName is the name of the listener whose tls.certificateRefs should reference the secret.
+	ListenerName string `json:"listenerName"`
+}
+
+// GCPProject defines a Google Cloud project and how to authenticate to it.
+type GCPProject struct {
+	// Project is the ID of the project.
+	Project string `json:"project"`
+
+	// CredentialsSecretRef references a service account key in JSON format. Defaults to
+	// the application default credentials, such as GKE workload identity.
+	// +optional
+	CredentialsSecretRef *SecretKeyRef `json:"credentialsSecretRef,omitempty"`
+
+	// Endpoint overrides the API endpoint, for example to use a local emulator. Plain
+	// http endpoints are used without authentication. Only DestinationProvider configs
+	// may set it, as the credentials are sent to it.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// CloudflareZone defines a Cloudflare zone and how custom certificates are uploaded to it.
+type CloudflareZone struct {
+	// ZoneID is the ID of the zone.
+	ZoneID string `json:"zoneID"`
+
+	// APITokenSecretRef references an API token with the Zone SSL and Certificates Edit permission.
+	APITokenSecretRef SecretKeyRef `json:"apiTokenSecretRef"`
+
+	// BundleMethod controls how Cloudflare builds the chain served to clients. Use force to
+	// serve the chain from the source secret as is. Defaults to ubiquitous.
+	// +kubebuilder:validation:Enum=ubiquitous;optimal;force
+	// +optional
+	BundleMethod string `json:"bundleMethod,omitempty"`
+
+	// CertificateType is the type of custom certificate. Defaults to sni_custom.
+	// +kubebuilder:validation:Enum=sni_custom;legacy_custom
+	// +optional
+	CertificateType string `json:"certificateType,omitempty"`
+
+	// Endpoint overrides the Cloudflare API base URL. Defaults to https://api.cloudflare.com/client/v4.
+	// Only DestinationProvider configs may set it, as the API token is sent to it.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Webhook defines an HTTP endpoint that receives the certificate. The certificate is
+// POSTed on sync and a DELETE is sent to the same URL on cleanup.
+type Webhook struct {
+	// URL of the endpoint. It must use https unless includePrivateKey is false.
+	// Redirects are not followed.
+	URL string `json:"url"`
+
+	// Format of the request body. JSON sends a JSON object, Multipart sends each PEM
+	// file as a part of a multipart/form-data body. Defaults to JSON.
+	// +kubebuilder:validation:Enum=JSON;Multipart
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Headers are added to every request.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// SigningSecretRef references the key used to sign requests with HMAC-SHA256. The
+	// signature of "<timestamp>.<body>" is sent in the X-Certauto-Signature header as
+	// sha256=<hex>, and the Unix timestamp in the X-Certauto-Timestamp header.
+	// +optional
+	SigningSecretRef *SecretKeyRef `json:"signingSecretRef,omitempty"`
+
+	// ClientCertificateSecretRef references a kubernetes.io/tls secret presented as the
+	// client certificate for mutual TLS. The namespace must be the namespace of the binding
+	// unless the config comes from a DestinationProvider.
+	// +optional
+	ClientCertificateSecretRef *SecretRef `json:"clientCertificateSecretRef,omitempty"`
+
+	// CABundleSecretRef references the PEM encoded CA bundle used to verify the endpoint.
+	// Defaults to the system roots.
+	// +optional
+	CABundleSecretRef *SecretKeyRef `json:"caBundleSecretRef,omitempty"`
+
+	// SuccessStatusCodes lists the response codes treated as success. Defaults to any 2xx code.
+	// +optional
+	SuccessStatusCodes []int32 `json:"successStatusCodes,omitempty"`
+
+	// Timeout of each request. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ObjectStorage defines an S3-compatible bucket that receives the certificate. The
+// tls.crt, tls.key and ca.crt objects are written below the prefix.
+type ObjectStorage struct {
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the object keys, for example certs/webapp.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint overrides the S3 endpoint, for example to use MinIO.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// UsePathStyle addresses the bucket in the URL path instead of the host name,
+	// as most S3-compatible services require.
+	// +optional
+	UsePathStyle bool `json:"usePathStyle,omitempty"`
+
+	// AccessKeyIDSecretRef references a static access key ID, used with secretAccessKeySecretRef.
+	// Defaults to the AWS default credential chain, such as IRSA.
+	// +optional
+	AccessKeyIDSecretRef *SecretKeyRef `json:"accessKeyIdSecretRef,omitempty"`
+
+	// SecretAccessKeySecretRef references the secret access key matching accessKeyIdSecretRef.
+	// +optional
+	SecretAccessKeySecretRef *SecretKeyRef `json:"secretAccessKeySecretRef,omitempty"`
+}
+
+// SSHTarget defines a remote host that receives the certificate files over SFTP.
+type SSHTarget struct {
+	// Host to connect to, as host or host:port. The port defaults to 22.
+	Host string `json:"host"`
+
+	// User to log in as.
+	User string `json:"user"`
+
+	// PrivateKeySecretRef references the PEM encoded private key used to log in.
+	PrivateKeySecretRef SecretKeyRef `json:"privateKeySecretRef"`
+
+	// KnownHostsSecretRef references the known_hosts entries used to verify the host key.
+	KnownHostsSecretRef SecretKeyRef `json:"knownHostsSecretRef"`
+
+	// Directory the files are written to. It is created if missing.
+	Directory string `json:"directory"`
+
+	// Files overrides the names of the files written to the directory.
+	// +optional
+	Files *SSHFiles `json:"files,omitempty"`
+
+	// UID is the numeric user that owns the files. Changing the owner usually
+	// requires logging in as root.
+	// +optional
+	UID *int32 `json:"uid,omitempty"`
+
+	// GID is the numeric group that owns the files.
+	// +optional
+	GID *int32 `json:"gid,omitempty"`
+
+	// FileMode is the octal permission mode of the certificate files. Defaults to 0644.
+	// +kubebuilder:validation:Pattern=`^0?[0-7]{3}$`
+	// +optional
+	FileMode string `json:"fileMode,omitempty"`
+
+	// PrivateKeyFileMode is the octal permission mode of the private key file. Defaults to 0600.
+	// +kubebuilder:validation:Pattern=`^0?[0-7]{3}$`
+	// +optional
+	PrivateKeyFileMode string `json:"privateKeyFileMode,omitempty"`
+
+	// PostDeployCommand is run on the host after the files changed, for example
+	// "sudo systemctl reload nginx". A non-zero exit status fails the sync.
+	// +optional
+	PostDeployCommand string `json:"postDeployCommand,omitempty"`
+}
+
+// SSHFiles defines the names of the files written to the remote directory.
+type SSHFiles struct {
+	// Certificate holds the leaf certificate. Defaults to cert.pem.
+	// +optional
+	Certificate string `json:"certificate,omitempty"`
+
+	// PrivateKey holds the private key. Defaults to privkey.pem.
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty"`
+
+	// Chain holds the intermediate and CA certificates. Defaults to chain.pem.
+	// +optional
+	Chain string `json:"chain,omitempty"`
+
+	// FullChain holds the leaf followed by the chain. Defaults to fullchain.pem.
+	// +optional
+	FullChain string `json:"fullChain,omitempty"`
+}
+
+// CertificateMapEntryRef references an entry of a Certificate Manager certificate map.
+type CertificateMapEntryRef struct {
+	// Map is the name of the certificate map.
+	Map string `json:"map"`
+
+	// Name of the entry.
+	Name string `json:"name"`
+
+	// Hostname the entry matches when it is created. Entries created without a hostname
+	// are the primary entry of the map.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// IAMServerCertificate defines an IAM server certificate. IAM server certificates
+// cannot be updated, so each certificate is uploaded as <name>-<hash>, the
+// references are moved to it and superseded certificates are deleted once the
+// grace period has passed.
+type IAMServerCertificate struct {
+	// Name is the prefix of the server certificate names.
+	// +kubebuilder:validation:MaxLength=117
+	Name string `json:"name"`
+
+	// Path of the server certificates. Must start with /cloudfront/ when
+	// cloudFrontDistributionIds is set. Defaults to /.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// GracePeriod is how long superseded certificates are kept before they are deleted. Defaults to 24h.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// LoadBalancerListeners lists the Classic Load Balancer HTTPS or SSL listeners that use the certificate.
+	// +optional
+	LoadBalancerListeners []ClassicLoadBalancerListener `json:"loadBalancerListeners,omitempty"`
+
+	// CloudFrontDistributionIDs lists the CloudFront distributions that use the certificate.
+	// +optional
+	CloudFrontDistributionIDs []string `json:"cloudFrontDistributionIds,omitempty"`
+}
+
+// LoadBalancerListener references an Application or Network Load Balancer listener.
+type LoadBalancerListener struct {
+	// ARN of the listener.
+	ARN string `json:"arn"`
+
+	// Default makes the certificate the default certificate of the listener. Otherwise
+	// it is added to the certificate list of the listener and selected through SNI.
+	// +optional
+	Default bool `json:"default,omitempty"`
+}
+
+// ClassicLoadBalancerListener references a listener of a Classic Load Balancer.
+type ClassicLoadBalancerListener struct {
+	// LoadBalancerName is the name of the load balancer.
+	LoadBalancerName string `json:"loadBalancerName"`
+
+	// Port is the load balancer port of the listener.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// VaultKV defines where a certificate is written in a Vault KV secrets engine
+// and how to authenticate to Vault. The secret holds the certificate, private_key
+// and chain keys.
+type VaultKV struct {
+	// Address of the Vault server, for example https://vault.example.com:8200.
+	Address string `json:"address"`
+
+	// Namespace is the Vault Enterprise namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// CABundleSecretRef references the PEM encoded CA bundle used to verify the Vault server.
+	// +optional
+	CABundleSecretRef *SecretKeyRef `json:"caBundleSecretRef,omitempty"`
+
+	// Mount is the path the KV secrets engine is mounted at. Defaults to secret.
+	// +optional
+	Mount string `json:"mount,omitempty"`
+
+	// Version of the KV secrets engine. Defaults to 2.
+	// +kubebuilder:validation:Enum=1;2
+	// +optional
+	Version int `json:"version,omitempty"`
+
+	// Path is a Go template for the secret path within the mount. Available fields are
+	// .Namespace and .Name of the source secret and .CommonName of the certificate.
+	// Defaults to {{ .Namespace }}/{{ .Name }}.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// MaxVersions is the number of versions to keep (KV version 2 only). Defaults to the mount setting.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxVersions *int `json:"maxVersions,omitempty"`
+
+	// CustomMetadata is added to the secret metadata (KV version 2 only). The fingerprint,
+	// source-namespace and source-name entries are always set from the source secret.
+	// +optional
+	CustomMetadata map[string]string `json:"customMetadata,omitempty"`
+
+	// Auth defines how to authenticate to Vault.
+	Auth VaultAuth `json:"auth"`
+}
+
+// VaultAuth defines how to authenticate to Vault. Exactly one method must be set.
+type VaultAuth struct {
+	// Kubernetes logs in with a token requested for a service account in the
+	// namespace of the binding. Only DestinationProvider configs may use it.
+	// +optional
+	Kubernetes *VaultKubernetesAuth `json:"kubernetes,omitempty"`
+
+	// AppRole logs in with a role ID and secret ID.
+	// +optional
+	AppRole *VaultAppRoleAuth `json:"appRole,omitempty"`
+}
+
+// VaultKubernetesAuth configures the Vault Kubernetes auth method. The
+// controller logs in with a short lived token with audience vault, requested
+// for a service account in the namespace of the binding. It may only be used by
+// DestinationProvider configs, and the service account must be annotated with
+// certauto.sanorg.in/allow-vault-token: "true".
+type VaultKubernetesAuth struct {
+	// Role is the Vault role to log in as.
+	Role string `json:"role"`
+
+	// ServiceAccountName is the service account in the namespace of the binding
+	// to request the token for.
+	ServiceAccountName string `json:"serviceAccountName"`
+
+	// MountPath is the path the auth method is mounted at. Defaults to kubernetes.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// VaultAppRoleAuth configures the Vault AppRole auth method.
+type VaultAppRoleAuth struct {
+	// RoleID is the role ID to log in with.
+	RoleID string `json:"roleId"`
+
+	// SecretIDSecretRef references the secret ID to log in with.
+	SecretIDSecretRef SecretKeyRef `json:"secretIdSecretRef"`
+
+	// MountPath is the path the auth method is mounted at. Defaults to approle.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// CAInjectionTarget references a cluster-scoped resource that receives the CA bundle.
+type CAInjectionTarget struct {
+	// Kind of the resource.
+	// +kubebuilder:validation:Enum=ValidatingWebhookConfiguration;MutatingWebhookConfiguration;APIService;CustomResourceDefinition
+	Kind string `json:"kind"`
+
+	// Name of the resource.
+	Name string `json:"name"`
+}
+
+// SecretTemplate defines metadata to propagate onto destination secrets.
+// Labels and annotations used by certauto for tracking always take precedence.
+type SecretTemplate struct {
+	// Labels to add to the destination secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to add to the destination secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // DestinationRule defines a destination where certificates should be synced.
@@ -63,16 +771,27 @@ type DestinationRule struct {
 	// Name is a unique identifier for this destination.
 	Name string `json:"name"`
 
-	// Type is the type of destination (AzureKeyVault, AWSACM, Kubernetes).
-	Type string `json:"type"`
+	// Type is the type of destination (AzureKeyVault, AWSACM, AWSSecretsManager, AWSIAMServerCertificate,
+	// GCPSecretManager, GCPCertificateManager, Cloudflare, Kubernetes, VaultKV, Webhook, SSH,
+	// ObjectStorage).
+	// +optional
+	Type string `json:"type,omitempty"`
 
 	// Config contains destination-specific configuration.
-	Config DestinationConfig `json:"config"`
+	// +optional
+	Config DestinationConfig `json:"config,omitempty"`
+
+	// Provider is the name of a DestinationProvider whose type and config are used instead
+	// of type and config. Unlike the config of a rule, the config of a provider may reference
+	// secrets in other namespaces.
+	// +optional
+	Provider string `json:"provider,omitempty"`
 }
 
 // SyncPolicy defines the sync policy for the certificate binding.
 type SyncPolicy struct {
-	// MaxRetries is the maximum number of retries before giving up.
+	// MaxRetries is the maximum number of retries before giving up. Deleting a
+	// destination of a deleted binding is attempted 5 times unless set.
 	// +optional
 	MaxRetries int32 `json:"maxRetries,omitempty"`
 
@@ -107,6 +826,25 @@ type DestinationStatus struct {
 	// RetryCount is the number of retry attempts.
 	// +optional
 	RetryCount int32 `json:"retryCount,omitempty"`
+
+	// Fingerprint is the SHA-256 fingerprint of the leaf certificate last synced to this destination.
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// ResourceName is the name of the external resource the certificate was synced to,
+	// for destinations that report one. For RemoteKubernetes destinations with a
+	// cluster selector it lists the selected clusters.
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// Provider is the DestinationProvider the destination was synced with, if any.
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Config is the config of the destination rule, recorded so that the destination
+	// can be deleted after the rule is removed. Not recorded for DestinationProvider rules.
+	// +optional
+	Config *DestinationConfig `json:"config,omitempty"`
 }
 
 // IssuerRef references a cert-manager Issuer or ClusterIssuer.
@@ -162,10 +900,14 @@ type CertificateBindingSpec struct {
 	Certificate *CertificateSpec `json:"certificate,omitempty"`
 
 	// SourceSecretRef is used if you already have a secret and don't want the controller to manage a Certificate.
+	// A secret in another namespace needs a ReferenceGrant in that namespace which
+	// allows CertificateBindings from the namespace of the binding.
 	// +optional
 	SourceSecretRef *SecretRef `json:"sourceSecretRef,omitempty"`
 
-	// DestinationRules defines where to sync the certificate.
+	// DestinationRules defines where to sync the certificate. What was synced to a
+	// destination with deletionPolicy Delete is deleted when its rule is removed or
+	// the binding is deleted.
 	// +optional
 	DestinationRules []DestinationRule `json:"destinationRules,omitempty"`
 
@@ -176,6 +918,15 @@ type CertificateBindingSpec struct {
 	// SyncPolicy defines the sync policy.
 	// +optional
 	SyncPolicy SyncPolicy `json:"syncPolicy,omitempty"`
+
+	// SecretTemplate defines labels and annotations applied to every Kubernetes destination.
+	// +optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+
+	// RestartTargets lists workloads to restart after the certificate changes and every
+	// destination was synced. The namespace defaults to the namespace of the binding.
+	// +optional
+	RestartTargets *RestartTargets `json:"restartTargets,omitempty"`
 }
 
 // CertificateBindingStatus defines the observed state of CertificateBinding.
@@ -203,6 +954,10 @@ type CertificateBindingStatus struct {
 	// ObservedGeneration is the latest generation of the CertificateBinding that was processed.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Fingerprint is the SHA-256 fingerprint of the leaf certificate last synced to every destination.
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,57 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DestinationProviderSpec defines a reusable destination.
+type DestinationProviderSpec struct {
+	// Type is the type of destination, as in CertificateBinding destination rules.
+	Type string `json:"type"`
+
+	// Config contains destination-specific configuration.
+	Config DestinationConfig `json:"config"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+
+// DestinationProvider is a named, reusable destination. Annotated resources
+// refer to providers by name and the CertificateBindings generated for them
+// copy the provider's type and config into their destination rules.
+type DestinationProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DestinationProviderSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DestinationProviderList contains a list of DestinationProvider.
+type DestinationProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DestinationProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DestinationProvider{}, &DestinationProviderList{})
+}
@@ -25,6 +25,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CAInjectionTarget) DeepCopyInto(out *CAInjectionTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CAInjectionTarget.
+func (in *CAInjectionTarget) DeepCopy() *CAInjectionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CAInjectionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateBinding) DeepCopyInto(out *CertificateBinding) {
 	*out = *in
@@ -100,9 +115,21 @@ func (in *CertificateBindingSpec) DeepCopyInto(out *CertificateBindingSpec) {
 	if in.DestinationRules != nil {
 		in, out := &in.DestinationRules, &out.DestinationRules
 		*out = make([]DestinationRule, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	out.SyncPolicy = in.SyncPolicy
+	if in.SecretTemplate != nil {
+		in, out := &in.SecretTemplate, &out.SecretTemplate
+		*out = new(SecretTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartTargets != nil {
+		in, out := &in.RestartTargets, &out.RestartTargets
+		*out = new(RestartTargets)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateBindingSpec.
@@ -148,6 +175,21 @@ func (in *CertificateBindingStatus) DeepCopy() *CertificateBindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateMapEntryRef) DeepCopyInto(out *CertificateMapEntryRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateMapEntryRef.
+func (in *CertificateMapEntryRef) DeepCopy() *CertificateMapEntryRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateMapEntryRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
 	*out = *in
@@ -179,9 +221,188 @@ func (in *CertificateSpec) DeepCopy() *CertificateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClassicLoadBalancerListener) DeepCopyInto(out *ClassicLoadBalancerListener) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClassicLoadBalancerListener.
+func (in *ClassicLoadBalancerListener) DeepCopy() *ClassicLoadBalancerListener {
+	if in == nil {
+		return nil
+	}
+	out := new(ClassicLoadBalancerListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudflareZone) DeepCopyInto(out *CloudflareZone) {
+	*out = *in
+	out.APITokenSecretRef = in.APITokenSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflareZone.
+func (in *CloudflareZone) DeepCopy() *CloudflareZone {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudflareZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSelector) DeepCopyInto(out *ClusterSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSelector.
+func (in *ClusterSelector) DeepCopy() *ClusterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DestinationConfig) DeepCopyInto(out *DestinationConfig) {
 	*out = *in
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]LoadBalancerListener, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretFields != nil {
+		in, out := &in.SecretFields, &out.SecretFields
+		*out = new(SecretFields)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemoteCluster != nil {
+		in, out := &in.RemoteCluster, &out.RemoteCluster
+		*out = new(RemoteCluster)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(ClusterSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayListenerRef)
+		**out = **in
+	}
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(GCPProject)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CertificateMapEntries != nil {
+		in, out := &in.CertificateMapEntries, &out.CertificateMapEntries
+		*out = make([]CertificateMapEntryRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.IAMServerCertificate != nil {
+		in, out := &in.IAMServerCertificate, &out.IAMServerCertificate
+		*out = new(IAMServerCertificate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultKV)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cloudflare != nil {
+		in, out := &in.Cloudflare, &out.Cloudflare
+		*out = new(CloudflareZone)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(Webhook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSH != nil {
+		in, out := &in.SSH, &out.SSH
+		*out = new(SSHTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectStorage != nil {
+		in, out := &in.ObjectStorage, &out.ObjectStorage
+		*out = new(ObjectStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InjectionTargets != nil {
+		in, out := &in.InjectionTargets, &out.InjectionTargets
+		*out = make([]CAInjectionTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretTemplate != nil {
+		in, out := &in.SecretTemplate, &out.SecretTemplate
+		*out = new(SecretTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutputFormats != nil {
+		in, out := &in.OutputFormats, &out.OutputFormats
+		*out = make([]OutputFormat, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DataTemplates != nil {
+		in, out := &in.DataTemplates, &out.DataTemplates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IncludePrivateKey != nil {
+		in, out := &in.IncludePrivateKey, &out.IncludePrivateKey
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Versioned != nil {
+		in, out := &in.Versioned, &out.Versioned
+		*out = new(VersionedSecrets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestartTargets != nil {
+		in, out := &in.RestartTargets, &out.RestartTargets
+		*out = new(RestartTargets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrivateKey != nil {
+		in, out := &in.PrivateKey, &out.PrivateKey
+		*out = new(PrivateKeyOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationConfig.
@@ -194,10 +415,84 @@ func (in *DestinationConfig) DeepCopy() *DestinationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DestinationProvider) DeepCopyInto(out *DestinationProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationProvider.
+func (in *DestinationProvider) DeepCopy() *DestinationProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(DestinationProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DestinationProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DestinationProviderList) DeepCopyInto(out *DestinationProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DestinationProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationProviderList.
+func (in *DestinationProviderList) DeepCopy() *DestinationProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(DestinationProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DestinationProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DestinationProviderSpec) DeepCopyInto(out *DestinationProviderSpec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationProviderSpec.
+func (in *DestinationProviderSpec) DeepCopy() *DestinationProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DestinationProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DestinationRule) DeepCopyInto(out *DestinationRule) {
 	*out = *in
-	out.Config = in.Config
+	in.Config.DeepCopyInto(&out.Config)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationRule.
@@ -217,6 +512,11 @@ func (in *DestinationStatus) DeepCopyInto(out *DestinationStatus) {
 		in, out := &in.LastSync, &out.LastSync
 		*out = (*in).DeepCopy()
 	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(DestinationConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationStatus.
@@ -229,6 +529,71 @@ func (in *DestinationStatus) DeepCopy() *DestinationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPProject) DeepCopyInto(out *GCPProject) {
+	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPProject.
+func (in *GCPProject) DeepCopy() *GCPProject {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPProject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayListenerRef) DeepCopyInto(out *GatewayListenerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayListenerRef.
+func (in *GatewayListenerRef) DeepCopy() *GatewayListenerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayListenerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IAMServerCertificate) DeepCopyInto(out *IAMServerCertificate) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LoadBalancerListeners != nil {
+		in, out := &in.LoadBalancerListeners, &out.LoadBalancerListeners
+		*out = make([]ClassicLoadBalancerListener, len(*in))
+		copy(*out, *in)
+	}
+	if in.CloudFrontDistributionIDs != nil {
+		in, out := &in.CloudFrontDistributionIDs, &out.CloudFrontDistributionIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IAMServerCertificate.
+func (in *IAMServerCertificate) DeepCopy() *IAMServerCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(IAMServerCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IssuerRef) DeepCopyInto(out *IssuerRef) {
 	*out = *in
@@ -244,6 +609,218 @@ func (in *IssuerRef) DeepCopy() *IssuerRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerListener) DeepCopyInto(out *LoadBalancerListener) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerListener.
+func (in *LoadBalancerListener) DeepCopy() *LoadBalancerListener {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStorage) DeepCopyInto(out *ObjectStorage) {
+	*out = *in
+	if in.AccessKeyIDSecretRef != nil {
+		in, out := &in.AccessKeyIDSecretRef, &out.AccessKeyIDSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.SecretAccessKeySecretRef != nil {
+		in, out := &in.SecretAccessKeySecretRef, &out.SecretAccessKeySecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorage.
+func (in *ObjectStorage) DeepCopy() *ObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputFormat) DeepCopyInto(out *OutputFormat) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputFormat.
+func (in *OutputFormat) DeepCopy() *OutputFormat {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputFormat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivateKeyOptions) DeepCopyInto(out *PrivateKeyOptions) {
+	*out = *in
+	if in.PassphraseSecretRef != nil {
+		in, out := &in.PassphraseSecretRef, &out.PassphraseSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivateKeyOptions.
+func (in *PrivateKeyOptions) DeepCopy() *PrivateKeyOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivateKeyOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteCluster) DeepCopyInto(out *RemoteCluster) {
+	*out = *in
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteCluster.
+func (in *RemoteCluster) DeepCopy() *RemoteCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartTargets) DeepCopyInto(out *RestartTargets) {
+	*out = *in
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]WorkloadRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartTargets.
+func (in *RestartTargets) DeepCopy() *RestartTargets {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartTargets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHFiles) DeepCopyInto(out *SSHFiles) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHFiles.
+func (in *SSHFiles) DeepCopy() *SSHFiles {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHFiles)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHTarget) DeepCopyInto(out *SSHTarget) {
+	*out = *in
+	out.PrivateKeySecretRef = in.PrivateKeySecretRef
+	out.KnownHostsSecretRef = in.KnownHostsSecretRef
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = new(SSHFiles)
+		**out = **in
+	}
+	if in.UID != nil {
+		in, out := &in.UID, &out.UID
+		*out = new(int32)
+		**out = **in
+	}
+	if in.GID != nil {
+		in, out := &in.GID, &out.GID
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHTarget.
+func (in *SSHTarget) DeepCopy() *SSHTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretFields) DeepCopyInto(out *SecretFields) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretFields.
+func (in *SecretFields) DeepCopy() *SecretFields {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretFields)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyRef) DeepCopyInto(out *SecretKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyRef.
+func (in *SecretKeyRef) DeepCopy() *SecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretRef) DeepCopyInto(out *SecretRef) {
 	*out = *in
@@ -259,6 +836,35 @@ func (in *SecretRef) DeepCopy() *SecretRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplate) DeepCopyInto(out *SecretTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplate.
+func (in *SecretTemplate) DeepCopy() *SecretTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncPolicy) DeepCopyInto(out *SyncPolicy) {
 	*out = *in
@@ -273,3 +879,174 @@ func (in *SyncPolicy) DeepCopy() *SyncPolicy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAppRoleAuth) DeepCopyInto(out *VaultAppRoleAuth) {
+	*out = *in
+	out.SecretIDSecretRef = in.SecretIDSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAppRoleAuth.
+func (in *VaultAppRoleAuth) DeepCopy() *VaultAppRoleAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAppRoleAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuth) DeepCopyInto(out *VaultAuth) {
+	*out = *in
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = new(VaultKubernetesAuth)
+		**out = **in
+	}
+	if in.AppRole != nil {
+		in, out := &in.AppRole, &out.AppRole
+		*out = new(VaultAppRoleAuth)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuth.
+func (in *VaultAuth) DeepCopy() *VaultAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKV) DeepCopyInto(out *VaultKV) {
+	*out = *in
+	if in.CABundleSecretRef != nil {
+		in, out := &in.CABundleSecretRef, &out.CABundleSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.MaxVersions != nil {
+		in, out := &in.MaxVersions, &out.MaxVersions
+		*out = new(int)
+		**out = **in
+	}
+	if in.CustomMetadata != nil {
+		in, out := &in.CustomMetadata, &out.CustomMetadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKV.
+func (in *VaultKV) DeepCopy() *VaultKV {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKV)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKubernetesAuth) DeepCopyInto(out *VaultKubernetesAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKubernetesAuth.
+func (in *VaultKubernetesAuth) DeepCopy() *VaultKubernetesAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKubernetesAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionedSecrets) DeepCopyInto(out *VersionedSecrets) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionedSecrets.
+func (in *VersionedSecrets) DeepCopy() *VersionedSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionedSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Webhook) DeepCopyInto(out *Webhook) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SigningSecretRef != nil {
+		in, out := &in.SigningSecretRef, &out.SigningSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+	if in.CABundleSecretRef != nil {
+		in, out := &in.CABundleSecretRef, &out.CABundleSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.SuccessStatusCodes != nil {
+		in, out := &in.SuccessStatusCodes, &out.SuccessStatusCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Webhook.
+func (in *Webhook) DeepCopy() *Webhook {
+	if in == nil {
+		return nil
+	}
+	out := new(Webhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRef) DeepCopyInto(out *WorkloadRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRef.
+func (in *WorkloadRef) DeepCopy() *WorkloadRef {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,530 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// reflectorAnnotationPrefix is the prefix of the emberstack kubernetes-reflector annotations.
+	reflectorAnnotationPrefix            = "reflector.v1.k8s.emberstack.com/"
+	reflectorAllowedAnnotation           = reflectorAnnotationPrefix + "reflection-allowed"
+	reflectorAllowedNamespacesAnnotation = reflectorAnnotationPrefix + "reflection-allowed-namespaces"
+	reflectorAutoEnabledAnnotation       = reflectorAnnotationPrefix + "reflection-auto-enabled"
+	reflectorAutoNamespacesAnnotation    = reflectorAnnotationPrefix + "reflection-auto-namespaces"
+	reflectorReflectsAnnotation          = reflectorAnnotationPrefix + "reflects"
+
+	// kubedSyncAnnotation is the kubed annotation holding a namespace label selector.
+	kubedSyncAnnotation = "kubed.appscode.com/sync"
+
+	// migratedFromAnnotation records which tool a generated binding replaces.
+	migratedFromAnnotation = "certauto.sanorg.in/migrated-from"
+)
+
+// migrationInput is the set of objects a migration is planned from.
+type migrationInput struct {
+	Secrets    []corev1.Secret
+	Namespaces []corev1.Namespace
+	// Mirrors are secrets outside the migrated namespace that reflect one of Secrets.
+	Mirrors []corev1.Secret
+}
+
+// migrationPlan is the result of planning a migration.
+type migrationPlan struct {
+	// Bindings replace the reflections of the migrated secrets.
+	Bindings []v1.CertificateBinding
+	// Migrated are the secrets whose reflector annotations can be stripped once
+	// the bindings are applied, including secrets mirrored by the reflector.
+	Migrated []types.NamespacedName
+	// Warnings describe secrets that could not be migrated exactly.
+	Warnings []string
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string     { return strings.Join(*s, ",") }
+func (s *stringList) Set(v string) error { *s = append(*s, v); return nil }
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var files stringList
+	var namespace string
+	var apply, strip bool
+	fs.Var(&files, "f", "YAML file with secrets and namespaces to migrate, or - for stdin. "+
+		"May be repeated. When omitted, secrets are read from the cluster.")
+	fs.StringVar(&namespace, "namespace", "", "Only migrate secrets in this namespace when reading from the cluster.")
+	fs.BoolVar(&apply, "apply", false, "Create or update the generated CertificateBindings in the cluster.")
+	fs.BoolVar(&strip, "strip-annotations", false,
+		"Remove reflector and kubed annotations from migrated secrets after applying. Requires --apply.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: %s migrate [flags]\n\n"+
+			"Generates CertificateBindings for TLS secrets reflected with kubernetes-reflector or kubed annotations.\n"+
+			"The cluster is reached through KUBECONFIG or the in-cluster configuration.\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if strip && !apply {
+		_, _ = fmt.Fprintln(stderr, "--strip-annotations requires --apply")
+		return 2
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	var c client.Client
+	if len(files) == 0 || apply {
+		cfg, err := ctrl.GetConfig()
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed to load kubeconfig: %v\n", err)
+			return 1
+		}
+		c, err = client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "failed to create client: %v\n", err)
+			return 1
+		}
+	}
+
+	var input *migrationInput
+	var err error
+	if len(files) > 0 {
+		input, err = loadMigrationFiles(files)
+	} else {
+		input, err = loadMigrationCluster(ctx, c, namespace)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	plan := planMigration(input)
+	for _, warning := range plan.Warnings {
+		_, _ = fmt.Fprintf(stderr, "warning: %s\n", warning)
+	}
+
+	if err := writeBindings(stdout, plan.Bindings); err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed to write bindings: %v\n", err)
+		return 1
+	}
+
+	if apply {
+		if err := applyBindings(ctx, c, plan.Bindings); err != nil {
+			_, _ = fmt.Fprintf(stderr, "%v\n", err)
+			return 1
+		}
+	}
+	if strip {
+		if err := stripReflectorAnnotations(ctx, c, plan.Migrated); err != nil {
+			_, _ = fmt.Fprintf(stderr, "%v\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// loadMigrationFiles reads Secrets and Namespaces from YAML or JSON files.
+func loadMigrationFiles(paths []string) (*migrationInput, error) {
+	input := &migrationInput{}
+	for _, path := range paths {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = f.Close() }()
+			r = f
+		}
+
+		decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+		for {
+			obj := &unstructured.Unstructured{}
+			if err := decoder.Decode(&obj.Object); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+			}
+			if err := input.add(obj); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", path, err)
+			}
+		}
+	}
+	return input, nil
+}
+
+// add adds a Secret or Namespace, or the Secrets and Namespaces of a List, to the input.
+func (in *migrationInput) add(obj *unstructured.Unstructured) error {
+	switch obj.GetKind() {
+	case "List", "SecretList", "NamespaceList":
+		return obj.EachListItem(func(item runtime.Object) error {
+			return in.add(item.(*unstructured.Unstructured))
+		})
+	case "Secret":
+		var secret corev1.Secret
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &secret); err != nil {
+			return err
+		}
+		in.Secrets = append(in.Secrets, secret)
+	case "Namespace":
+		var ns corev1.Namespace
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ns); err != nil {
+			return err
+		}
+		in.Namespaces = append(in.Namespaces, ns)
+	}
+	return nil
+}
+
+// loadMigrationCluster reads Secrets and Namespaces from the cluster. When
+// namespace is set, mirrors of its secrets are read from every namespace, so
+// that they are migrated before the source annotations are stripped.
+func loadMigrationCluster(ctx context.Context, c client.Client, namespace string) (*migrationInput, error) {
+	var secrets corev1.SecretList
+	if err := c.List(ctx, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %v", err)
+	}
+	var namespaces corev1.NamespaceList
+	if err := c.List(ctx, &namespaces); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+
+	input := &migrationInput{Namespaces: namespaces.Items}
+	for _, secret := range secrets.Items {
+		switch {
+		case namespace == "" || secret.Namespace == namespace:
+			input.Secrets = append(input.Secrets, secret)
+		case strings.HasPrefix(secret.Annotations[reflectorReflectsAnnotation], namespace+"/"):
+			input.Mirrors = append(input.Mirrors, secret)
+		}
+	}
+	return input, nil
+}
+
+// planMigration builds a CertificateBinding with Kubernetes destinations for
+// every TLS secret reflected by kubernetes-reflector or kubed.
+//
+// Namespace patterns and selectors are expanded against the namespaces known
+// at migration time; the generated bindings list namespaces explicitly. Like
+// kubernetes-reflector, only secrets with reflection-allowed are reflected,
+// and only into the namespaces matching reflection-allowed-namespaces.
+func planMigration(in *migrationInput) *migrationPlan {
+	plan := &migrationPlan{}
+
+	// Mirrors created by hand point at their source with the reflects annotation
+	mirrors := map[types.NamespacedName][]types.NamespacedName{}
+	for _, secret := range slices.Concat(in.Secrets, in.Mirrors) {
+		source := secret.Annotations[reflectorReflectsAnnotation]
+		if source == "" {
+			continue
+		}
+		namespace, name, ok := strings.Cut(source, "/")
+		if !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("secret %s/%s: invalid reflects annotation %q", secret.Namespace, secret.Name, source))
+			continue
+		}
+		key := types.NamespacedName{Name: name, Namespace: namespace}
+		mirrors[key] = append(mirrors[key], types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace})
+	}
+
+	for _, secret := range in.Secrets {
+		key := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+
+		var targets, migratedMirrors []types.NamespacedName
+		var migratedFrom []string
+
+		allowed := secret.Annotations[reflectorAllowedAnnotation] == "true"
+		allowedNamespaces := secret.Annotations[reflectorAllowedNamespacesAnnotation]
+		if secret.Annotations[reflectorAutoEnabledAnnotation] == "true" {
+			if allowed {
+				autoNamespaces := secret.Annotations[reflectorAutoNamespacesAnnotation]
+				if strings.TrimSpace(autoNamespaces) == "" {
+					autoNamespaces = allowedNamespaces
+				}
+				namespaces, warnings := in.matchReflectorNamespaces(autoNamespaces)
+				for _, namespace := range namespaces {
+					if !reflectorNamespaceAllowed(allowedNamespaces, namespace) {
+						continue
+					}
+					targets = append(targets, types.NamespacedName{Name: secret.Name, Namespace: namespace})
+				}
+				plan.Warnings = append(plan.Warnings, prefixWarnings(key, warnings)...)
+				migratedFrom = append(migratedFrom, "kubernetes-reflector")
+			} else {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("secret %s: reflection-auto-enabled ignored, reflection-allowed is not set", key))
+			}
+		}
+		for _, mirror := range mirrors[key] {
+			if !allowed || !reflectorNamespaceAllowed(allowedNamespaces, mirror.Namespace) {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("secret %s: mirror %s skipped, reflection into its namespace is not allowed", key, mirror))
+				continue
+			}
+			targets = append(targets, mirror)
+			migratedMirrors = append(migratedMirrors, mirror)
+			if !slices.Contains(migratedFrom, "kubernetes-reflector") {
+				migratedFrom = append(migratedFrom, "kubernetes-reflector")
+			}
+		}
+		if selector, ok := secret.Annotations[kubedSyncAnnotation]; ok {
+			namespaces, warnings := in.matchKubedNamespaces(selector)
+			for _, namespace := range namespaces {
+				targets = append(targets, types.NamespacedName{Name: secret.Name, Namespace: namespace})
+			}
+			plan.Warnings = append(plan.Warnings, prefixWarnings(key, warnings)...)
+			migratedFrom = append(migratedFrom, "kubed")
+		}
+
+		if len(migratedFrom) == 0 {
+			continue
+		}
+		if secret.Type != corev1.SecretTypeTLS {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("secret %s: skipped, only kubernetes.io/tls secrets can be migrated", key))
+			continue
+		}
+
+		binding := migrationBinding(secret, targets, migratedFrom)
+		if len(binding.Spec.DestinationRules) == 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("secret %s: no target namespaces found", key))
+			continue
+		}
+		plan.Bindings = append(plan.Bindings, binding)
+		plan.Migrated = append(plan.Migrated, key)
+		plan.Migrated = append(plan.Migrated, migratedMirrors...)
+	}
+
+	return plan
+}
+
+// migrationBinding builds the CertificateBinding reflecting secret to targets.
+func migrationBinding(secret corev1.Secret, targets []types.NamespacedName, migratedFrom []string) v1.CertificateBinding {
+	binding := v1.CertificateBinding{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1.GroupVersion.String(), Kind: "CertificateBinding"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name + "-reflection",
+			Namespace:   secret.Namespace,
+			Annotations: map[string]string{migratedFromAnnotation: strings.Join(migratedFrom, ",")},
+		},
+		Spec: v1.CertificateBindingSpec{
+			SourceSecretRef: &v1.SecretRef{Name: secret.Name, Namespace: secret.Namespace},
+		},
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	for _, target := range slices.Compact(targets) {
+		if target.Namespace == secret.Namespace && target.Name == secret.Name {
+			continue
+		}
+		name := "reflect-" + target.Namespace
+		if target.Name != secret.Name {
+			name = fmt.Sprintf("%s-%s", name, target.Name)
+		}
+		binding.Spec.DestinationRules = append(binding.Spec.DestinationRules, v1.DestinationRule{
+			Name: name,
+			Type: "Kubernetes",
+			Config: v1.DestinationConfig{
+				TargetNamespace:  target.Namespace,
+				TargetSecretName: target.Name,
+			},
+		})
+	}
+	return binding
+}
+
+// matchReflectorNamespaces resolves a kubernetes-reflector namespace list. Each
+// entry is a regular expression matched against the whole namespace name, and
+// an empty list matches every namespace.
+func (in *migrationInput) matchReflectorNamespaces(value string) ([]string, []string) {
+	var namespaces, warnings []string
+
+	patterns := strings.Split(value, ",")
+	if strings.TrimSpace(value) == "" {
+		patterns = []string{".*"}
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		// Plain names are kept even when the namespace is not known
+		if regexp.QuoteMeta(pattern) == pattern {
+			namespaces = append(namespaces, pattern)
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid namespace pattern %q: %v", pattern, err))
+			continue
+		}
+		matched := 0
+		for _, ns := range in.Namespaces {
+			if re.MatchString(ns.Name) {
+				namespaces = append(namespaces, ns.Name)
+				matched++
+			}
+		}
+		warnings = append(warnings, fmt.Sprintf("namespace pattern %q expanded to the %d namespaces known now", pattern, matched))
+	}
+	return namespaces, warnings
+}
+
+// reflectorNamespaceAllowed reports whether namespace matches a
+// kubernetes-reflector reflection-allowed-namespaces list, where an empty list
+// allows every namespace.
+func reflectorNamespaceAllowed(value, namespace string) bool {
+	if strings.TrimSpace(value) == "" {
+		return true
+	}
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err == nil && re.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKubedNamespaces resolves a kubed sync annotation, a label selector on
+// namespaces where an empty selector matches every namespace.
+func (in *migrationInput) matchKubedNamespaces(value string) ([]string, []string) {
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("invalid namespace selector %q: %v", value, err)}
+	}
+
+	var namespaces []string
+	for _, ns := range in.Namespaces {
+		if selector.Matches(labels.Set(ns.Labels)) {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, []string{fmt.Sprintf("namespace selector %q expanded to the %d namespaces known now", value, len(namespaces))}
+}
+
+func prefixWarnings(secret types.NamespacedName, warnings []string) []string {
+	prefixed := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		prefixed = append(prefixed, fmt.Sprintf("secret %s: %s", secret, warning))
+	}
+	return prefixed
+}
+
+// writeBindings writes bindings as a multi-document YAML stream.
+func writeBindings(w io.Writer, bindings []v1.CertificateBinding) error {
+	for i := range bindings {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&bindings[i])
+		if err != nil {
+			return err
+		}
+		delete(obj, "status")
+		unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBindings creates or updates the generated bindings.
+func applyBindings(ctx context.Context, c client.Client, bindings []v1.CertificateBinding) error {
+	for _, desired := range bindings {
+		binding := &v1.CertificateBinding{ObjectMeta: metav1.ObjectMeta{Name: desired.Name, Namespace: desired.Namespace}}
+		if _, err := controllerutil.CreateOrUpdate(ctx, c, binding, func() error {
+			if binding.Annotations == nil {
+				binding.Annotations = map[string]string{}
+			}
+			binding.Annotations[migratedFromAnnotation] = desired.Annotations[migratedFromAnnotation]
+			binding.Spec.SourceSecretRef = desired.Spec.SourceSecretRef
+			binding.Spec.DestinationRules = desired.Spec.DestinationRules
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to apply certificatebinding %s/%s: %v", desired.Namespace, desired.Name, err)
+		}
+	}
+	return nil
+}
+
+// stripReflectorAnnotations removes the kubernetes-reflector and kubed
+// annotations from the migrated secrets.
+func stripReflectorAnnotations(ctx context.Context, c client.Client, secrets []types.NamespacedName) error {
+	for _, key := range secrets {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, key, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get secret %s: %v", key, err)
+		}
+
+		original := secret.DeepCopy()
+		for annotation := range secret.Annotations {
+			if strings.HasPrefix(annotation, reflectorAnnotationPrefix) || annotation == kubedSyncAnnotation {
+				delete(secret.Annotations, annotation)
+			}
+		}
+		if len(secret.Annotations) == len(original.Annotations) {
+			continue
+		}
+		if err := c.Patch(ctx, secret, client.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to strip annotations from secret %s: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const migrationManifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-a
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-b
+  labels:
+    certs: wildcard
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: infra
+---
+apiVersion: v1
+kind: Secret
+type: kubernetes.io/tls
+metadata:
+  name: wildcard-tls
+  namespace: infra
+  annotations:
+    reflector.v1.k8s.emberstack.com/reflection-allowed: "true"
+    reflector.v1.k8s.emberstack.com/reflection-allowed-namespaces: "team-a,legacy"
+    reflector.v1.k8s.emberstack.com/reflection-auto-enabled: "true"
+    reflector.v1.k8s.emberstack.com/reflection-auto-namespaces: "team-.*,legacy"
+---
+apiVersion: v1
+kind: Secret
+type: kubernetes.io/tls
+metadata:
+  name: api-tls
+  namespace: infra
+  annotations:
+    reflector.v1.k8s.emberstack.com/reflection-allowed: "true"
+    kubed.appscode.com/sync: "certs=wildcard"
+---
+apiVersion: v1
+kind: Secret
+type: kubernetes.io/tls
+metadata:
+  name: api-cert
+  namespace: team-a
+  annotations:
+    reflector.v1.k8s.emberstack.com/reflects: "infra/api-tls"
+---
+apiVersion: v1
+kind: Secret
+type: kubernetes.io/tls
+metadata:
+  name: internal-tls
+  namespace: team-b
+  annotations:
+    reflector.v1.k8s.emberstack.com/reflects: "infra/internal-tls"
+---
+apiVersion: v1
+kind: Secret
+type: kubernetes.io/tls
+metadata:
+  name: internal-tls
+  namespace: infra
+---
+apiVersion: v1
+kind: Secret
+type: Opaque
+metadata:
+  name: registry-credentials
+  namespace: infra
+  annotations:
+    kubed.appscode.com/sync: ""
+`
+
+func TestPlanMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(path, []byte(migrationManifests), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	input, err := loadMigrationFiles([]string{path})
+	if err != nil {
+		t.Fatalf("loadMigrationFiles() error = %v", err)
+	}
+	if len(input.Secrets) != 6 || len(input.Namespaces) != 3 {
+		t.Fatalf("loaded %d secrets and %d namespaces, want 6 and 3", len(input.Secrets), len(input.Namespaces))
+	}
+
+	plan := planMigration(input)
+	if len(plan.Bindings) != 2 {
+		t.Fatalf("got %d bindings, want 2", len(plan.Bindings))
+	}
+
+	wildcard := plan.Bindings[0]
+	if wildcard.Name != "wildcard-tls-reflection" || wildcard.Annotations[migratedFromAnnotation] != "kubernetes-reflector" {
+		t.Errorf("unexpected binding %s annotations %v", wildcard.Name, wildcard.Annotations)
+	}
+	var targets []string
+	for _, rule := range wildcard.Spec.DestinationRules {
+		targets = append(targets, rule.Config.TargetNamespace+"/"+rule.Config.TargetSecretName)
+	}
+	if got := strings.Join(targets, ","); got != "legacy/wildcard-tls,team-a/wildcard-tls" {
+		t.Errorf("wildcard-tls targets = %s", got)
+	}
+
+	api := plan.Bindings[1]
+	if api.Annotations[migratedFromAnnotation] != "kubernetes-reflector,kubed" {
+		t.Errorf("api-tls migrated from %q", api.Annotations[migratedFromAnnotation])
+	}
+	if rules := api.Spec.DestinationRules; len(rules) != 2 ||
+		rules[0].Name != "reflect-team-a-api-cert" || rules[0].Config.TargetSecretName != "api-cert" ||
+		rules[1].Config.TargetNamespace != "team-b" {
+		t.Errorf("unexpected api-tls destination rules %+v", rules)
+	}
+
+	if mirror := (types.NamespacedName{Name: "api-cert", Namespace: "team-a"}); !slices.Contains(plan.Migrated, mirror) {
+		t.Errorf("mirror secret %s should be stripped, migrated = %v", mirror, plan.Migrated)
+	}
+	// kubernetes-reflector does not update mirrors of secrets without reflection-allowed
+	if mirror := (types.NamespacedName{Name: "internal-tls", Namespace: "team-b"}); slices.Contains(plan.Migrated, mirror) {
+		t.Errorf("mirror secret %s should not be stripped, migrated = %v", mirror, plan.Migrated)
+	}
+	if !strings.Contains(strings.Join(plan.Warnings, "\n"), "mirror team-b/internal-tls skipped") {
+		t.Errorf("expected a warning for the disallowed mirror, got %v", plan.Warnings)
+	}
+
+	if !strings.Contains(strings.Join(plan.Warnings, "\n"), "registry-credentials: skipped") {
+		t.Errorf("expected a warning for the opaque secret, got %v", plan.Warnings)
+	}
+
+	var out bytes.Buffer
+	if err := writeBindings(&out, plan.Bindings); err != nil {
+		t.Fatalf("writeBindings() error = %v", err)
+	}
+	if strings.Contains(out.String(), "status") || strings.Count(out.String(), "kind: CertificateBinding") != 2 {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestLoadMigrationClusterMirrors(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-tls", Namespace: "infra"}, Type: corev1.SecretTypeTLS}
+	mirror := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "api-cert",
+		Namespace:   "team-a",
+		Annotations: map[string]string{reflectorReflectsAnnotation: "infra/api-tls"},
+	}}
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"}}
+	c := fake.NewClientBuilder().WithObjects(source, mirror, unrelated).Build()
+
+	input, err := loadMigrationCluster(context.Background(), c, "infra")
+	if err != nil {
+		t.Fatalf("loadMigrationCluster() error = %v", err)
+	}
+	if len(input.Secrets) != 1 || input.Secrets[0].Name != "api-tls" {
+		t.Errorf("unexpected secrets %v", input.Secrets)
+	}
+	if len(input.Mirrors) != 1 || input.Mirrors[0].Name != "api-cert" {
+		t.Errorf("mirrors in other namespaces should be loaded, got %v", input.Mirrors)
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// CertificateReconciler generates CertificateBindings for cert-manager
+// Certificates annotated with certauto.sanorg.in/sync-to or
+// certauto.sanorg.in/reflect-to.
+type CertificateReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("certificate", req.NamespacedName)
+
+	var cert certmanagerv1.Certificate
+	if err := r.Get(ctx, req.NamespacedName, &cert); err != nil {
+		if errors.IsNotFound(err) {
+			// Generated bindings are garbage collected through their owner reference
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	secrets := []types.NamespacedName{{Name: cert.Spec.SecretName, Namespace: cert.Namespace}}
+	if err := reconcileGeneratedBindings(ctrl.LoggerInto(ctx, log), r.Client, r.Scheme, &cert, secrets); err != nil {
+		log.Error(err, "Failed to reconcile generated certificatebindings")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *CertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certmanagerv1.Certificate{}).
+		Owns(&certautov1.CertificateBinding{}).
+		Watches(
+			&certautov1.DestinationProvider{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &certmanagerv1.CertificateList{}, SyncToAnnotation, obj.GetName())
+			}),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &certmanagerv1.CertificateList{}, ReflectToAnnotation, obj.GetName())
+			}),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}
@@ -3,10 +3,14 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 
 	"github.com/go-logr/logr"
@@ -15,12 +19,15 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
@@ -47,13 +54,50 @@ type DestinationPlugin interface {
 	Delete(ctx context.Context, config certautov1.DestinationConfig) error
 }
 
+// ResourceNamer is implemented by destination plugins that sync to a single
+// external resource, whose name is recorded in the destination status.
+type ResourceNamer interface {
+	ResourceName(secret *corev1.Secret, config certautov1.DestinationConfig) string
+}
+
+// ResourceSyncer is implemented by destination plugins whose external resource
+// is identified by an ID assigned on creation. The ID recorded in the
+// destination status is passed back so the resource is replaced, not
+// duplicated, on renewal. It is called instead of Sync.
+type ResourceSyncer interface {
+	SyncResource(ctx context.Context, secret *corev1.Secret, config certautov1.DestinationConfig, resourceName string) (string, error)
+}
+
+// ResourceDeleter is implemented by destination plugins whose external
+// resource cannot be found from the config alone. The name recorded in the
+// destination status is passed to delete it. It is called instead of Delete.
+type ResourceDeleter interface {
+	DeleteResource(ctx context.Context, config certautov1.DestinationConfig, resourceName string) error
+}
+
+// CleanupFinalizer keeps a CertificateBinding until its destinations are deleted.
+const CleanupFinalizer = "certauto.sanorg.in/cleanup"
+
+// defaultCleanupAttempts is how often deleting a destination of a deleted
+// binding is attempted when the sync policy sets no maximum.
+const defaultCleanupAttempts = 5
+
 // +kubebuilder:rbac:groups=sanorg.in,resources=certificatebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=sanorg.in,resources=certificatebindings/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=sanorg.in,resources=certificatebindings/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apiregistration.k8s.io,resources=apiservices,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
 
 func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("certificatebinding", req.NamespacedName)
@@ -67,6 +111,16 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	// 1.5 Delete the destinations of a deleted binding
+	if !binding.DeletionTimestamp.IsZero() {
+		return r.finalizeBinding(ctx, &binding)
+	}
+	if controllerutil.AddFinalizer(&binding, CleanupFinalizer) {
+		if err := r.Update(ctx, &binding); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// 2. Handle cert-manager Certificate management if configured
 	sourceSecretName := ""
 	sourceSecretNamespace := ""
@@ -89,6 +143,14 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 	} else if binding.Spec.SourceSecretRef != nil {
 		sourceSecretName = binding.Spec.SourceSecretRef.Name
 		sourceSecretNamespace = binding.Spec.SourceSecretRef.Namespace
+		source := types.NamespacedName{Name: sourceSecretName, Namespace: sourceSecretNamespace}
+		allowed, err := r.sourceSecretAllowed(ctx, &binding, source)
+		if err != nil {
+			return r.updateStatusWithError(ctx, &binding, fmt.Sprintf("Failed to check source secret reference: %v", err))
+		}
+		if !allowed {
+			return r.updateStatusWithError(ctx, &binding, fmt.Sprintf("Source secret %s is not in the namespace of the binding and no ReferenceGrant allows it", source))
+		}
 	} else {
 		return r.updateStatusWithError(ctx, &binding, "Neither Certificate nor SourceSecretRef provided")
 	}
@@ -119,11 +181,40 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 	allSynced := true
 	var destStatuses []certautov1.DestinationStatus
 
+	fingerprint, err := getCertFingerprint(secret)
+	if err != nil {
+		return r.updateStatusWithError(ctx, &binding, fmt.Sprintf("Failed to fingerprint certificate: %v", err))
+	}
+	previous := map[string]certautov1.DestinationStatus{}
+	for _, status := range binding.Status.Destinations {
+		previous[status.Name] = status
+	}
+
+	var requeueAfter time.Duration
 	for _, dest := range binding.Spec.DestinationRules {
 		destStatus := certautov1.DestinationStatus{
-			Name: dest.Name,
-			Type: dest.Type,
+			Name:     dest.Name,
+			Type:     dest.Type,
+			Provider: dest.Provider,
+		}
+		if dest.Provider == "" {
+			config := dest.Config
+			destStatus.Config = &config
+		}
+
+		dest, err := r.resolveDestinationRule(ctx, dest)
+		if err != nil {
+			destStatus.State = certautov1.SyncStateFailed
+			destStatus.Error = err.Error()
+			destStatus.Fingerprint = previous[dest.Name].Fingerprint
+			destStatus.ResourceName = previous[dest.Name].ResourceName
+			destStatuses = append(destStatuses, destStatus)
+			allSynced = false
+			custommetrics.SyncTotal.WithLabelValues(dest.Type, "error").Inc()
+			continue
 		}
+		destStatus.Type = dest.Type
+		destCtx := plugins.WithBindingScope(ctx, plugins.BindingScope{Namespace: binding.Namespace, Provider: dest.Provider})
 
 		plugin, exists := r.plugins[dest.Type]
 		if !exists {
@@ -142,15 +233,32 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 			destStatus.Error = "Dry Run: No action taken"
 			now := metav1.Now()
 			destStatus.LastSync = &now
-		} else if err := plugin.Sync(ctx, secret, dest.Config); err != nil {
+			destStatus.Fingerprint = previous[dest.Name].Fingerprint
+			destStatus.ResourceName = previous[dest.Name].ResourceName
+		} else if resourceName, err := syncDestination(destCtx, plugin, secret, destinationConfigFor(&binding, dest), previous[dest.Name].ResourceName); err != nil {
 			destStatus.State = certautov1.SyncStateFailed
 			destStatus.Error = err.Error()
+			destStatus.Fingerprint = previous[dest.Name].Fingerprint
+			destStatus.ResourceName = resourceName
+			allSynced = false
+			custommetrics.SyncTotal.WithLabelValues(dest.Type, "error").Inc()
+		} else if err := r.restartDestinationWorkloads(ctx, &binding, dest, previous[dest.Name].Fingerprint, fingerprint); err != nil {
+			// Keep the previous fingerprint so the restart is retried
+			destStatus.State = certautov1.SyncStateFailed
+			destStatus.Error = fmt.Sprintf("Failed to restart workloads: %v", err)
+			destStatus.Fingerprint = previous[dest.Name].Fingerprint
+			destStatus.ResourceName = resourceName
 			allSynced = false
 			custommetrics.SyncTotal.WithLabelValues(dest.Type, "error").Inc()
 		} else {
+			destStatus.Fingerprint = fingerprint
+			destStatus.ResourceName = resourceName
 			destStatus.State = certautov1.SyncStateSynced
 			now := metav1.Now()
 			destStatus.LastSync = &now
+			if after := pruneRequeueAfter(dest); after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+				requeueAfter = after
+			}
 			custommetrics.SyncTotal.WithLabelValues(dest.Type, "success").Inc()
 			custommetrics.SyncDuration.WithLabelValues(dest.Type).Observe(time.Since(startTime).Seconds())
 
@@ -162,6 +270,41 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		destStatuses = append(destStatuses, destStatus)
 	}
 
+	// 4.2 Delete the destinations of removed rules
+	for _, status := range binding.Status.Destinations {
+		if slices.ContainsFunc(binding.Spec.DestinationRules, func(dest certautov1.DestinationRule) bool { return dest.Name == status.Name }) {
+			continue
+		}
+		if binding.Spec.DryRun {
+			log.Info("[DRY-RUN] Would delete removed destination", "destination", status.Name, "type", status.Type)
+			destStatuses = append(destStatuses, status)
+			continue
+		}
+		if err := r.cleanupDestination(ctx, &binding, status); err != nil {
+			log.Error(err, "Failed to delete removed destination", "destination", status.Name)
+			status.State = certautov1.SyncStateFailed
+			status.Error = fmt.Sprintf("Failed to delete removed destination: %v", err)
+			destStatuses = append(destStatuses, status)
+			allSynced = false
+			continue
+		}
+		log.Info("Deleted removed destination", "destination", status.Name, "type", status.Type)
+	}
+
+	// 4.5 Restart the binding's workloads once every destination has the new certificate
+	var restartErr error
+	if allSynced && !binding.Spec.DryRun {
+		if certificateChanged(binding.Status.Fingerprint, fingerprint) && binding.Spec.RestartTargets != nil {
+			restartErr = restartWorkloads(ctx, r.Client, binding.Spec.RestartTargets, fingerprint, binding.Namespace)
+		}
+		if restartErr != nil {
+			log.Error(restartErr, "Failed to restart workloads")
+			allSynced = false
+		} else {
+			binding.Status.Fingerprint = fingerprint
+		}
+	}
+
 	// 5. Update Status
 	binding.Status.Destinations = destStatuses
 	binding.Status.Ready = allSynced
@@ -177,6 +320,13 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 			Reason:  "DryRun",
 			Message: "Dry run successful: operations simulated",
 		})
+	} else if restartErr != nil {
+		meta.SetStatusCondition(&binding.Status.Conditions, metav1.Condition{
+			Type:    "Ready",
+			Status:  metav1.ConditionFalse,
+			Reason:  "RestartFailed",
+			Message: fmt.Sprintf("Failed to restart workloads: %v", restartErr),
+		})
 	} else if allSynced {
 		meta.SetStatusCondition(&binding.Status.Conditions, metav1.Condition{
 			Type:    "Ready",
@@ -197,7 +347,40 @@ func (r *CertificateBindingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	if restartErr != nil {
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// pruneRequeueAfter returns when a destination with versioned secrets must be
+// synced again to delete superseded generations, or zero.
+func pruneRequeueAfter(dest certautov1.DestinationRule) time.Duration {
+	if dest.Type != "Kubernetes" || dest.Config.Versioned == nil {
+		return 0
+	}
+	// A zero grace period still requeues for generations kept while pods use them
+	return max(plugins.GenerationGracePeriod(dest.Config), time.Minute)
+}
+
+// restartDestinationWorkloads restarts the workloads of a Kubernetes
+// destination when the certificate synced to it changed. Workloads must be in
+// the target namespace of the destination or the namespace of the binding.
+func (r *CertificateBindingReconciler) restartDestinationWorkloads(ctx context.Context, binding *certautov1.CertificateBinding, dest certautov1.DestinationRule, previous, fingerprint string) error {
+	if dest.Type != "Kubernetes" || dest.Config.RestartTargets == nil || !certificateChanged(previous, fingerprint) {
+		return nil
+	}
+	namespaces := []string{binding.Namespace}
+	if dest.Config.TargetNamespace != "" && dest.Config.TargetNamespace != binding.Namespace {
+		namespaces = []string{dest.Config.TargetNamespace, binding.Namespace}
+	}
+	return restartWorkloads(ctx, r.Client, dest.Config.RestartTargets, fingerprint, namespaces...)
+}
+
+// certificateChanged reports whether a certificate was synced before and has
+// since been replaced. The first sync is not a change.
+func certificateChanged(previous, fingerprint string) bool {
+	return previous != "" && previous != fingerprint
 }
 
 func (r *CertificateBindingReconciler) validateTLSSecret(secret *corev1.Secret) error {
@@ -233,6 +416,188 @@ func (r *CertificateBindingReconciler) validateTLSSecret(secret *corev1.Secret)
 	return nil
 }
 
+// resolveDestinationRule returns the rule with the type and config of its
+// DestinationProvider, if it references one. The config is always read from the
+// provider so that only provider configs are trusted to reference other
+// namespaces.
+func (r *CertificateBindingReconciler) resolveDestinationRule(ctx context.Context, dest certautov1.DestinationRule) (certautov1.DestinationRule, error) {
+	if dest.Provider == "" {
+		return dest, nil
+	}
+	provider := &certautov1.DestinationProvider{}
+	if err := r.Get(ctx, types.NamespacedName{Name: dest.Provider}, provider); err != nil {
+		if errors.IsNotFound(err) {
+			return dest, fmt.Errorf("destination provider %s does not exist", dest.Provider)
+		}
+		return dest, fmt.Errorf("failed to get destination provider %s: %v", dest.Provider, err)
+	}
+	dest.Type = provider.Spec.Type
+	dest.Config = provider.Spec.Config
+	return dest, nil
+}
+
+// destinationConfigFor returns the destination config with binding-level defaults applied.
+func destinationConfigFor(binding *certautov1.CertificateBinding, dest certautov1.DestinationRule) certautov1.DestinationConfig {
+	config := dest.Config
+	config.SecretTemplate = mergeSecretTemplate(binding.Spec.SecretTemplate, dest.Config.SecretTemplate)
+	return config
+}
+
+// syncDestination syncs the secret to a destination and returns the name of the
+// external resource to record in the status. On failure the previous name is
+// returned unless the plugin reports a new one.
+func syncDestination(ctx context.Context, plugin DestinationPlugin, secret *corev1.Secret, config certautov1.DestinationConfig, previousResource string) (string, error) {
+	if syncer, ok := plugin.(ResourceSyncer); ok {
+		resourceName, err := syncer.SyncResource(ctx, secret, config, previousResource)
+		if resourceName == "" {
+			resourceName = previousResource
+		}
+		return resourceName, err
+	}
+	if err := plugin.Sync(ctx, secret, config); err != nil {
+		return previousResource, err
+	}
+	if namer, ok := plugin.(ResourceNamer); ok {
+		return namer.ResourceName(secret, config), nil
+	}
+	return previousResource, nil
+}
+
+// deleteDestination deletes the external resource of a destination, using the
+// name recorded in the status for plugins that implement ResourceDeleter.
+func deleteDestination(ctx context.Context, plugin DestinationPlugin, config certautov1.DestinationConfig, resourceName string) error {
+	if deleter, ok := plugin.(ResourceDeleter); ok {
+		return deleter.DeleteResource(ctx, config, resourceName)
+	}
+	return plugin.Delete(ctx, config)
+}
+
+// cleanupDestination deletes what was synced to the destination of status. The
+// rule is read from the spec, or from the status once it was removed from the
+// spec. Destinations that were never synced or whose deletion policy is not
+// Delete are skipped.
+func (r *CertificateBindingReconciler) cleanupDestination(ctx context.Context, binding *certautov1.CertificateBinding, status certautov1.DestinationStatus) error {
+	logger := r.Log.WithValues("certificatebinding", client.ObjectKeyFromObject(binding))
+
+	if status.Fingerprint == "" && status.ResourceName == "" {
+		return nil
+	}
+
+	var dest certautov1.DestinationRule
+	if i := slices.IndexFunc(binding.Spec.DestinationRules, func(dest certautov1.DestinationRule) bool { return dest.Name == status.Name }); i >= 0 {
+		dest = binding.Spec.DestinationRules[i]
+	} else if status.Provider != "" {
+		dest = certautov1.DestinationRule{Name: status.Name, Provider: status.Provider}
+	} else if status.Config != nil {
+		dest = certautov1.DestinationRule{Name: status.Name, Type: status.Type, Config: *status.Config}
+	} else {
+		logger.Info("Config of removed destination was not recorded, skipping deletion", "destination", status.Name)
+		return nil
+	}
+
+	if dest.Provider != "" {
+		if err := r.Get(ctx, types.NamespacedName{Name: dest.Provider}, &certautov1.DestinationProvider{}); errors.IsNotFound(err) {
+			logger.Info("Destination provider no longer exists, skipping deletion", "destination", status.Name, "provider", dest.Provider)
+			return nil
+		}
+	}
+	dest, err := r.resolveDestinationRule(ctx, dest)
+	if err != nil {
+		return err
+	}
+	if dest.Config.DeletionPolicy != certautov1.DeletionPolicyDelete {
+		logger.Info("Retaining destination", "destination", status.Name, "type", dest.Type)
+		return nil
+	}
+	plugin, exists := r.plugins[dest.Type]
+	if !exists {
+		return fmt.Errorf("unknown destination type: %s", dest.Type)
+	}
+
+	destCtx := plugins.WithBindingScope(ctx, plugins.BindingScope{Namespace: binding.Namespace, Provider: dest.Provider})
+	return deleteDestination(destCtx, plugin, destinationConfigFor(binding, dest), status.ResourceName)
+}
+
+// finalizeBinding deletes every destination of a deleted binding and then
+// removes the cleanup finalizer. The finalizer is kept while deletions fail,
+// until a destination has failed as often as the sync policy allows. It is then
+// left in place so that a destination refusing the deletion does not block the
+// binding forever.
+func (r *CertificateBindingReconciler) finalizeBinding(ctx context.Context, binding *certautov1.CertificateBinding) (ctrl.Result, error) {
+	log := r.Log.WithValues("certificatebinding", client.ObjectKeyFromObject(binding))
+
+	if !controllerutil.ContainsFinalizer(binding, CleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	maxAttempts := binding.Spec.SyncPolicy.MaxRetries
+	if maxAttempts <= 0 {
+		maxAttempts = defaultCleanupAttempts
+	}
+
+	if !binding.Spec.DryRun {
+		var remaining []certautov1.DestinationStatus
+		for _, status := range binding.Status.Destinations {
+			if err := r.cleanupDestination(ctx, binding, status); err != nil {
+				status.RetryCount++
+				if status.RetryCount >= maxAttempts {
+					log.Error(err, "Giving up deleting destination, leaving it in place", "destination", status.Name, "attempts", status.RetryCount)
+					continue
+				}
+				log.Error(err, "Failed to delete destination", "destination", status.Name)
+				status.State = certautov1.SyncStateFailed
+				status.Error = fmt.Sprintf("Failed to delete destination: %v", err)
+				remaining = append(remaining, status)
+				continue
+			}
+			log.Info("Deleted destination", "destination", status.Name, "type", status.Type)
+		}
+		if len(remaining) > 0 {
+			binding.Status.Destinations = remaining
+			if err := r.Status().Update(ctx, binding); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(binding, CleanupFinalizer)
+	if err := r.Update(ctx, binding); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// mergeSecretTemplate merges two secret templates, with values from override taking precedence.
+func mergeSecretTemplate(base, override *certautov1.SecretTemplate) *certautov1.SecretTemplate {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := &certautov1.SecretTemplate{
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	maps.Copy(merged.Labels, base.Labels)
+	maps.Copy(merged.Labels, override.Labels)
+	maps.Copy(merged.Annotations, base.Annotations)
+	maps.Copy(merged.Annotations, override.Annotations)
+	return merged
+}
+
+// getCertFingerprint returns the hex encoded SHA-256 fingerprint of the leaf certificate.
+func getCertFingerprint(secret *corev1.Secret) (string, error) {
+	block, _ := pem.Decode(secret.Data["tls.crt"])
+	if block == nil {
+		return "", fmt.Errorf("failed to decode certificate PEM")
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func getCertExpiry(secret *corev1.Secret) (time.Time, error) {
 	certData := secret.Data["tls.crt"]
 	block, _ := pem.Decode(certData)
@@ -280,6 +645,27 @@ func (r *CertificateBindingReconciler) ensureCertificate(ctx context.Context, bi
 	return err
 }
 
+// sourceSecretAllowed reports whether binding may read its source secret. A
+// secret in another namespace needs a ReferenceGrant in that namespace which
+// allows CertificateBindings from the namespace of the binding. Bindings
+// generated for a Gateway may also use the grant that lets the Gateway
+// reference the secret.
+func (r *CertificateBindingReconciler) sourceSecretAllowed(ctx context.Context, binding *certautov1.CertificateBinding, secret types.NamespacedName) (bool, error) {
+	if secret.Namespace == binding.Namespace {
+		return true, nil
+	}
+	granted, err := referenceGranted(ctx, r.Client, certautov1.GroupVersion.Group, "CertificateBinding", binding.Namespace, secret)
+	if err != nil || granted {
+		return granted, err
+	}
+	if owner := metav1.GetControllerOf(binding); owner != nil && owner.Kind == "Gateway" {
+		if gv, err := schema.ParseGroupVersion(owner.APIVersion); err == nil && gv.Group == gatewayv1.GroupName {
+			return referenceGranted(ctx, r.Client, gatewayv1.GroupName, "Gateway", binding.Namespace, secret)
+		}
+	}
+	return false, nil
+}
+
 func (r *CertificateBindingReconciler) updateStatusWithError(ctx context.Context, binding *certautov1.CertificateBinding, errorMsg string) (ctrl.Result, error) {
 	binding.Status.Ready = false
 	meta.SetStatusCondition(&binding.Status.Conditions, metav1.Condition{
@@ -299,9 +685,22 @@ func (r *CertificateBindingReconciler) SetupWithManager(mgr ctrl.Manager) error
 	r.plugins = make(map[string]DestinationPlugin)
 	r.plugins["AzureKeyVault"] = &plugins.AzureKeyVaultPlugin{Client: r.Client}
 	r.plugins["AWSACM"] = &plugins.AWSACMPlugin{Client: r.Client}
+	r.plugins["AWSSecretsManager"] = &plugins.AWSSecretsManagerPlugin{Client: r.Client}
+	r.plugins["AWSIAMServerCertificate"] = &plugins.AWSIAMServerCertificatePlugin{Client: r.Client}
+	r.plugins["GCPSecretManager"] = &plugins.GCPSecretManagerPlugin{Client: r.Client}
+	r.plugins["GCPCertificateManager"] = &plugins.GCPCertificateManagerPlugin{Client: r.Client}
 	r.plugins["Kubernetes"] = &plugins.KubernetesReflectorPlugin{Client: r.Client}
-
-	return ctrl.NewControllerManagedBy(mgr).
+	r.plugins["ConfigMap"] = &plugins.ConfigMapPlugin{Client: r.Client}
+	r.plugins["CAInjection"] = &plugins.CAInjectionPlugin{Client: r.Client}
+	r.plugins["RemoteKubernetes"] = &plugins.RemoteKubernetesPlugin{Client: r.Client}
+	r.plugins["GatewayAPI"] = &plugins.GatewayPlugin{Client: r.Client}
+	r.plugins["VaultKV"] = &plugins.VaultKVPlugin{Client: r.Client}
+	r.plugins["Cloudflare"] = &plugins.CloudflarePlugin{Client: r.Client}
+	r.plugins["Webhook"] = &plugins.WebhookPlugin{Client: r.Client}
+	r.plugins["SSH"] = &plugins.SSHPlugin{Client: r.Client}
+	r.plugins["ObjectStorage"] = &plugins.ObjectStoragePlugin{Client: r.Client}
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		For(&certautov1.CertificateBinding{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&certmanagerv1.Certificate{}).
 		Owns(&corev1.Secret{}).
@@ -310,12 +709,55 @@ func (r *CertificateBindingReconciler) SetupWithManager(mgr ctrl.Manager) error
 			handler.EnqueueRequestsFromMapFunc(r.mapSecretToBinding),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
-		Complete(r)
+		Watches(
+			&certautov1.DestinationProvider{},
+			handler.EnqueueRequestsFromMapFunc(r.mapProviderToBindings),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+
+	// Cluster API is optional, only watch Clusters when its CRDs are installed
+	if gvk, err := plugins.ClusterAPIClusterGVK(mgr.GetRESTMapper()); err == nil {
+		cluster := &metav1.PartialObjectMetadata{}
+		cluster.SetGroupVersionKind(gvk)
+		bldr = bldr.WatchesMetadata(
+			cluster,
+			handler.EnqueueRequestsFromMapFunc(r.mapClusterToBindings),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		)
+	}
+
+	return bldr.Complete(r)
+}
+
+// mapProviderToBindings enqueues the bindings with a destination rule that
+// references a DestinationProvider, so that config changes are synced.
+func (r *CertificateBindingReconciler) mapProviderToBindings(ctx context.Context, obj client.Object) []ctrl.Request {
+	var list certautov1.CertificateBindingList
+	if err := r.List(ctx, &list); err != nil {
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, b := range list.Items {
+		for _, dest := range b.Spec.DestinationRules {
+			if dest.Provider == obj.GetName() {
+				requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
 }
 
 func (r *CertificateBindingReconciler) mapSecretToBinding(ctx context.Context, obj client.Object) []ctrl.Request {
 	secret, ok := obj.(*corev1.Secret)
-	if !ok || secret.Type != corev1.SecretTypeTLS {
+	if !ok {
+		return nil
+	}
+	if isClusterAPIKubeconfig(secret) {
+		return r.mapKubeconfigToBindings(ctx, secret)
+	}
+	if secret.Type != corev1.SecretTypeTLS {
 		return nil
 	}
 
@@ -341,3 +783,49 @@ func (r *CertificateBindingReconciler) mapSecretToBinding(ctx context.Context, o
 	}
 	return requests
 }
+
+// isClusterAPIKubeconfig reports whether secret is the kubeconfig Cluster API
+// wrote for a workload cluster.
+func isClusterAPIKubeconfig(secret *corev1.Secret) bool {
+	clusterName := secret.Labels[plugins.ClusterAPIClusterNameLabel]
+	return clusterName != "" && secret.Name == plugins.ClusterAPIKubeconfigSecretName(clusterName)
+}
+
+// mapKubeconfigToBindings enqueues the bindings with a cluster selector that
+// may match the cluster of a Cluster API kubeconfig secret, so that new
+// workload clusters receive their certificates as soon as they are reachable.
+func (r *CertificateBindingReconciler) mapKubeconfigToBindings(ctx context.Context, secret *corev1.Secret) []ctrl.Request {
+	return r.bindingsSelectingClusters(ctx, secret.Namespace)
+}
+
+// mapClusterToBindings enqueues the bindings with a cluster selector that may
+// match a Cluster API Cluster, so that label changes add or remove the cluster.
+func (r *CertificateBindingReconciler) mapClusterToBindings(ctx context.Context, obj client.Object) []ctrl.Request {
+	return r.bindingsSelectingClusters(ctx, obj.GetNamespace())
+}
+
+// bindingsSelectingClusters returns requests for the bindings with a cluster
+// selector in namespace. The selector namespace defaults to the namespace of
+// the binding.
+func (r *CertificateBindingReconciler) bindingsSelectingClusters(ctx context.Context, namespace string) []ctrl.Request {
+	var list certautov1.CertificateBindingList
+	if err := r.List(ctx, &list); err != nil {
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, b := range list.Items {
+		for _, dest := range b.Spec.DestinationRules {
+			dest, err := r.resolveDestinationRule(ctx, dest)
+			selector := dest.Config.ClusterSelector
+			if err != nil || selector == nil {
+				continue
+			}
+			if selector.Namespace == namespace || (selector.Namespace == "" && b.Namespace == namespace) {
+				requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
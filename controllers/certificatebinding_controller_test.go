@@ -1,16 +1,35 @@
 package controllers
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
 )
 
 func createTestCert(priv *rsa.PrivateKey, notAfter time.Time) ([]byte, error) {
@@ -102,3 +121,592 @@ func TestValidateTLSSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeSecretTemplate(t *testing.T) {
+	base := &certautov1.SecretTemplate{
+		Labels:      map[string]string{"team": "platform", "tier": "base"},
+		Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+	}
+	override := &certautov1.SecretTemplate{
+		Labels: map[string]string{"tier": "frontend"},
+	}
+
+	merged := mergeSecretTemplate(base, override)
+	if merged.Labels["team"] != "platform" || merged.Labels["tier"] != "frontend" {
+		t.Errorf("unexpected merged labels: %v", merged.Labels)
+	}
+	if merged.Annotations["reloader.stakater.com/match"] != "true" {
+		t.Errorf("unexpected merged annotations: %v", merged.Annotations)
+	}
+
+	if got := mergeSecretTemplate(nil, override); got != override {
+		t.Errorf("mergeSecretTemplate(nil, override) = %v, want override", got)
+	}
+	if got := mergeSecretTemplate(base, nil); got != base {
+		t.Errorf("mergeSecretTemplate(base, nil) = %v, want base", got)
+	}
+}
+
+func TestIsClusterAPIKubeconfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretName string
+		labels     map[string]string
+		want       bool
+	}{
+		{"kubeconfig", "edge-1-kubeconfig", map[string]string{"cluster.x-k8s.io/cluster-name": "edge-1"}, true},
+		{"other cluster secret", "edge-1-ca", map[string]string{"cluster.x-k8s.io/cluster-name": "edge-1"}, false},
+		{"unlabelled", "edge-1-kubeconfig", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tt.secretName, Labels: tt.labels}}
+			if got := isClusterAPIKubeconfig(secret); got != tt.want {
+				t.Errorf("isClusterAPIKubeconfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindingsSelectingClusters(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := certautov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	newBinding := func(name, namespace string, selector *certautov1.ClusterSelector) *certautov1.CertificateBinding {
+		return &certautov1.CertificateBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: certautov1.CertificateBindingSpec{
+				DestinationRules: []certautov1.DestinationRule{
+					{Name: "edge", Type: "RemoteKubernetes", Config: certautov1.DestinationConfig{ClusterSelector: selector}},
+				},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newBinding("local", "fleet", &certautov1.ClusterSelector{}),
+		newBinding("explicit", "cert-manager", &certautov1.ClusterSelector{Namespace: "fleet"}),
+		newBinding("elsewhere", "cert-manager", &certautov1.ClusterSelector{}),
+		newBinding("remote", "fleet", nil),
+	).Build()
+	r := &CertificateBindingReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+
+	var got []string
+	for _, req := range r.bindingsSelectingClusters(context.Background(), "fleet") {
+		got = append(got, req.String())
+	}
+	slices.Sort(got)
+	want := []string{"cert-manager/explicit", "fleet/local"}
+	if !slices.Equal(got, want) {
+		t.Errorf("bindingsSelectingClusters() = %v, want %v", got, want)
+	}
+}
+
+func TestIngressReconcilerGeneratesBindings(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := certautov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "apps",
+			UID:         "ingress-uid",
+			Annotations: map[string]string{SyncToAnnotation: "aws-acm-prod, azure-kv"},
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"www.example.com"}, SecretName: "web-tls"},
+				{Hosts: []string{"example.com"}, SecretName: "web-tls"},
+			},
+		},
+	}
+	providers := []client.Object{
+		&certautov1.DestinationProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "aws-acm-prod"},
+			Spec:       certautov1.DestinationProviderSpec{Type: "AWSACM", Config: certautov1.DestinationConfig{Region: "eu-west-1"}},
+		},
+		&certautov1.DestinationProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "azure-kv"},
+			Spec:       certautov1.DestinationProviderSpec{Type: "AzureKeyVault", Config: certautov1.DestinationConfig{KeyVaultName: "prod-kv"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(providers, ingress)...).Build()
+	r := &IngressReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "apps"}}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	var list certautov1.CertificateBindingList
+	if err := c.List(ctx, &list, client.InNamespace("apps")); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("got %d bindings, want 1", len(list.Items))
+	}
+	binding := list.Items[0]
+	if binding.Name != "ingress-web-web-tls" || !metav1.IsControlledBy(&binding, ingress) {
+		t.Errorf("unexpected binding %s owned by %v", binding.Name, binding.OwnerReferences)
+	}
+	if ref := binding.Spec.SourceSecretRef; ref == nil || ref.Name != "web-tls" || ref.Namespace != "apps" {
+		t.Errorf("unexpected source secret %+v", ref)
+	}
+	rules := binding.Spec.DestinationRules
+	if len(rules) != 2 || rules[0].Type != "AWSACM" || rules[0].Provider != "aws-acm-prod" || rules[1].Name != "azure-kv" {
+		t.Errorf("unexpected destination rules %+v", rules)
+	}
+
+	// Removing the annotation deletes the generated binding
+	if err := c.Get(ctx, req.NamespacedName, ingress); err != nil {
+		t.Fatal(err)
+	}
+	ingress.Annotations = nil
+	if err := c.Update(ctx, ingress); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := c.List(ctx, &list, client.InNamespace("apps")); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("got %d bindings after removing the annotation, want 0", len(list.Items))
+	}
+}
+
+func TestGatewayReconcilerReferenceGrants(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, install := range []func(*runtime.Scheme) error{clientgoscheme.AddToScheme, certautov1.AddToScheme, gatewayv1.Install, gatewayv1beta1.Install} {
+		if err := install(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certRef := func(namespace, name string) gatewayv1.SecretObjectReference {
+		ref := gatewayv1.SecretObjectReference{Name: gatewayv1.ObjectName(name)}
+		if namespace != "" {
+			ns := gatewayv1.Namespace(namespace)
+			ref.Namespace = &ns
+		}
+		return ref
+	}
+	gateway := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "public",
+			Namespace:   "edge",
+			UID:         "gateway-uid",
+			Annotations: map[string]string{SyncToAnnotation: "aws-acm-prod"},
+		},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: "example",
+			Listeners: []gatewayv1.Listener{{
+				Name:     "https",
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				TLS: &gatewayv1.ListenerTLSConfig{CertificateRefs: []gatewayv1.SecretObjectReference{
+					certRef("", "edge-tls"),
+					certRef("certs", "shared-tls"),
+					certRef("granted", "granted-tls"),
+				}},
+			}},
+		},
+	}
+	grant := &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-gateways", Namespace: "granted"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "Gateway", Namespace: "edge"}},
+			To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Secret"}},
+		},
+	}
+	provider := &certautov1.DestinationProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws-acm-prod"},
+		Spec:       certautov1.DestinationProviderSpec{Type: "AWSACM", Config: certautov1.DestinationConfig{Region: "eu-west-1"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gateway, grant, provider).Build()
+	r := &GatewayReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "public", Namespace: "edge"}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	var list certautov1.CertificateBindingList
+	if err := c.List(ctx, &list, client.InNamespace("edge")); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, binding := range list.Items {
+		got = append(got, binding.Spec.SourceSecretRef.Namespace+"/"+binding.Spec.SourceSecretRef.Name)
+	}
+	slices.Sort(got)
+	// certs/shared-tls is not granted to Gateways in edge
+	want := []string{"edge/edge-tls", "granted/granted-tls"}
+	if !slices.Equal(got, want) {
+		t.Errorf("bindings for %v, want %v", got, want)
+	}
+
+	// The generated binding may read the secret through the Gateway's grant
+	for i := range list.Items {
+		binding := &list.Items[i]
+		if binding.Spec.SourceSecretRef.Namespace != "granted" {
+			continue
+		}
+		br := &CertificateBindingReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+		allowed, err := br.sourceSecretAllowed(ctx, binding, types.NamespacedName{Name: "granted-tls", Namespace: "granted"})
+		if err != nil || !allowed {
+			t.Errorf("sourceSecretAllowed() = %v, %v, want the Gateway grant to apply", allowed, err)
+		}
+	}
+}
+
+func TestSecretReconcilerReflectTo(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := certautov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "wildcard-tls",
+			Namespace:   "cert-manager",
+			Annotations: map[string]string{ReflectToAnnotation: "app-frontend,cert-manager,app-backend,kube-system,missing"},
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	allowed := map[string]string{ReflectionAllowedLabel: "true"}
+	namespaces := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-frontend", Labels: allowed}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-backend", Labels: allowed}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithObjects(namespaces...).Build()
+	r := &SecretReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	ctx := context.Background()
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "wildcard-tls", Namespace: "cert-manager"}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	binding := &certautov1.CertificateBinding{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "secret-wildcard-tls-wildcard-tls", Namespace: "cert-manager"}, binding); err != nil {
+		t.Fatalf("generated binding not found: %v", err)
+	}
+	rules := binding.Spec.DestinationRules
+	if len(rules) != 2 {
+		t.Fatalf("got %d destination rules, want 2 (the source and namespaces without the label are skipped)", len(rules))
+	}
+	for i, namespace := range []string{"app-frontend", "app-backend"} {
+		if rules[i].Type != "Kubernetes" || rules[i].Config.TargetNamespace != namespace || rules[i].Config.TargetSecretName != "wildcard-tls" {
+			t.Errorf("unexpected destination rule %+v", rules[i])
+		}
+	}
+}
+
+func TestGeneratedBindingName(t *testing.T) {
+	owner := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"}}
+	if got := generatedBindingName("Ingress", owner, types.NamespacedName{Name: "web", Namespace: "apps"}); got != "ingress-web-web" {
+		t.Errorf("generatedBindingName() = %q, want ingress-web-web", got)
+	}
+	if got := generatedBindingName("Ingress", owner, types.NamespacedName{Name: "tls", Namespace: "shared"}); got != "ingress-web-tls-shared" {
+		t.Errorf("generatedBindingName() = %q, want ingress-web-tls-shared", got)
+	}
+
+	long := strings.Repeat("a", 200)
+	owner.Name = long
+	first := generatedBindingName("Ingress", owner, types.NamespacedName{Name: long + "-1", Namespace: "apps"})
+	second := generatedBindingName("Ingress", owner, types.NamespacedName{Name: long + "-2", Namespace: "apps"})
+	if len(first) > 253 || len(second) > 253 {
+		t.Errorf("generated names are longer than 253 characters: %d, %d", len(first), len(second))
+	}
+	if first == second {
+		t.Errorf("truncated names are equal: %q", first)
+	}
+}
+
+func TestRestartWorkloads(t *testing.T) {
+	web := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"}}
+	cache := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "apps", Labels: map[string]string{"uses-tls": "true"}}}
+	other := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "apps"}}
+	c := fake.NewClientBuilder().WithObjects(web, cache, other).Build()
+	ctx := context.Background()
+
+	targets := &certautov1.RestartTargets{
+		Workloads: []certautov1.WorkloadRef{{Kind: "Deployment", Name: "web"}, {Kind: "Deployment", Name: "missing"}},
+		Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"uses-tls": "true"}},
+	}
+	if err := restartWorkloads(ctx, c, targets, "abc", "apps"); err != nil {
+		t.Fatalf("restartWorkloads() error = %v", err)
+	}
+
+	restarted := map[string]client.Object{"web": &appsv1.Deployment{}, "cache": &appsv1.StatefulSet{}}
+	for name, obj := range restarted {
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "apps"}, obj); err != nil {
+			t.Fatal(err)
+		}
+		if podTemplate(obj).Annotations[RestartedAtAnnotation] == "" || podTemplate(obj).Annotations[RestartedForAnnotation] != "abc" {
+			t.Errorf("%s was not restarted", name)
+		}
+	}
+
+	// A retry for the same certificate leaves restarted workloads alone
+	web.Spec.Template.Annotations = nil
+	if err := c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "apps"}, web); err != nil {
+		t.Fatal(err)
+	}
+	web.Spec.Template.Annotations[RestartedAtAnnotation] = "earlier"
+	if err := c.Update(ctx, web); err != nil {
+		t.Fatal(err)
+	}
+	if err := restartWorkloads(ctx, c, targets, "abc", "apps"); err != nil {
+		t.Fatalf("restartWorkloads() error = %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "apps"}, web); err != nil {
+		t.Fatal(err)
+	}
+	if web.Spec.Template.Annotations[RestartedAtAnnotation] != "earlier" {
+		t.Error("workload already restarted for the certificate was restarted again")
+	}
+
+	if err := restartWorkloads(ctx, c, &certautov1.RestartTargets{Namespace: "kube-system"}, "def", "apps"); err == nil {
+		t.Error("restart targets outside the allowed namespaces should be rejected")
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "agent", Namespace: "apps"}, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.Spec.Template.Annotations[RestartedAtAnnotation]; ok {
+		t.Error("unselected daemonset was restarted")
+	}
+
+	if certificateChanged("", "abc") || certificateChanged("abc", "abc") || !certificateChanged("abc", "def") {
+		t.Error("certificateChanged() should only report replaced certificates")
+	}
+}
+
+type fakeResourcePlugin struct {
+	id        string
+	err       error
+	deleteErr error
+	deleted   []string
+}
+
+func (p *fakeResourcePlugin) Name() string { return "Fake" }
+
+func (p *fakeResourcePlugin) Sync(ctx context.Context, secret *corev1.Secret, config certautov1.DestinationConfig) error {
+	return fmt.Errorf("Sync should not be called for ResourceSyncer plugins")
+}
+
+func (p *fakeResourcePlugin) SyncResource(ctx context.Context, secret *corev1.Secret, config certautov1.DestinationConfig, resourceName string) (string, error) {
+	return p.id, p.err
+}
+
+func (p *fakeResourcePlugin) CheckExists(ctx context.Context, config certautov1.DestinationConfig) (bool, error) {
+	return false, nil
+}
+
+func (p *fakeResourcePlugin) Delete(ctx context.Context, config certautov1.DestinationConfig) error {
+	return fmt.Errorf("Delete should not be called for ResourceDeleter plugins")
+}
+
+func (p *fakeResourcePlugin) DeleteResource(ctx context.Context, config certautov1.DestinationConfig, resourceName string) error {
+	if p.deleteErr != nil {
+		return p.deleteErr
+	}
+	p.deleted = append(p.deleted, resourceName)
+	return nil
+}
+
+func TestSyncDestinationResourceName(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{}
+
+	name, err := syncDestination(ctx, &fakeResourcePlugin{id: "cert-2"}, secret, certautov1.DestinationConfig{}, "cert-1")
+	if err != nil || name != "cert-2" {
+		t.Errorf("syncDestination() = %q, %v, want cert-2", name, err)
+	}
+
+	name, err = syncDestination(ctx, &fakeResourcePlugin{err: fmt.Errorf("upload failed")}, secret, certautov1.DestinationConfig{}, "cert-1")
+	if err == nil || name != "cert-1" {
+		t.Errorf("syncDestination() = %q, %v, want the previous resource name kept on failure", name, err)
+	}
+}
+
+func TestCertificateBindingCleanup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := certautov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	cert, _ := createTestCert(priv, time.Now().Add(24*time.Hour))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "apps"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": cert,
+			"tls.key": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)}),
+		},
+	}
+	deletePolicy := certautov1.DestinationConfig{DeletionPolicy: certautov1.DeletionPolicyDelete}
+	binding := &certautov1.CertificateBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"},
+		Spec: certautov1.CertificateBindingSpec{
+			SourceSecretRef: &certautov1.SecretRef{Name: "web-tls", Namespace: "apps"},
+			DestinationRules: []certautov1.DestinationRule{
+				{Name: "kept", Type: "Fake", Config: deletePolicy},
+				{Name: "retained", Type: "Fake"},
+			},
+		},
+		Status: certautov1.CertificateBindingStatus{
+			Destinations: []certautov1.DestinationStatus{
+				{Name: "removed", Type: "Fake", Fingerprint: "old", ResourceName: "cert-1", Config: &deletePolicy},
+				{Name: "removed-retained", Type: "Fake", Fingerprint: "old", ResourceName: "cert-0", Config: &certautov1.DestinationConfig{}},
+				{Name: "never-synced", Type: "Fake", Config: &deletePolicy},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, binding).WithStatusSubresource(binding).Build()
+	plugin := &fakeResourcePlugin{id: "cert-2"}
+	r := &CertificateBindingReconciler{Client: c, Log: logr.Discard(), Scheme: scheme, plugins: map[string]DestinationPlugin{"Fake": plugin}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "apps"}}
+
+	// Removing a rule deletes its destination by the recorded resource name,
+	// unless the deletion policy retains it
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if !slices.Equal(plugin.deleted, []string{"cert-1"}) {
+		t.Errorf("deleted %v, want cert-1", plugin.deleted)
+	}
+	if err := c.Get(ctx, req.NamespacedName, binding); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(binding.Finalizers, CleanupFinalizer) {
+		t.Errorf("finalizers = %v, want %s", binding.Finalizers, CleanupFinalizer)
+	}
+	if len(binding.Status.Destinations) != 2 || binding.Status.Destinations[0].ResourceName != "cert-2" {
+		t.Errorf("unexpected destination status %+v", binding.Status.Destinations)
+	}
+
+	// Deleting the binding deletes the remaining destinations before it is removed
+	if err := c.Delete(ctx, binding); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if !slices.Equal(plugin.deleted, []string{"cert-1", "cert-2"}) {
+		t.Errorf("deleted %v, want cert-1 and cert-2", plugin.deleted)
+	}
+	if err := c.Get(ctx, req.NamespacedName, binding); !errors.IsNotFound(err) {
+		t.Errorf("binding should be removed once its destinations are deleted, got %v", err)
+	}
+}
+
+func TestFinalizeBindingGivesUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := certautov1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	binding := &certautov1.CertificateBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", Finalizers: []string{CleanupFinalizer}},
+		Spec: certautov1.CertificateBindingSpec{
+			DestinationRules: []certautov1.DestinationRule{
+				{Name: "in-use", Type: "Fake", Config: certautov1.DestinationConfig{DeletionPolicy: certautov1.DeletionPolicyDelete}},
+			},
+			SyncPolicy: certautov1.SyncPolicy{MaxRetries: 2},
+		},
+		Status: certautov1.CertificateBindingStatus{
+			Destinations: []certautov1.DestinationStatus{{Name: "in-use", Type: "Fake", ResourceName: "cert-1"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).WithStatusSubresource(binding).Build()
+	plugin := &fakeResourcePlugin{deleteErr: fmt.Errorf("certificate is in use")}
+	r := &CertificateBindingReconciler{Client: c, Log: logr.Discard(), Scheme: scheme, plugins: map[string]DestinationPlugin{"Fake": plugin}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "apps"}}
+	if err := c.Delete(ctx, binding); err != nil {
+		t.Fatal(err)
+	}
+
+	// The deletion is retried until the sync policy's maximum is reached
+	result, err := r.Reconcile(ctx, req)
+	if err != nil || result.RequeueAfter == 0 {
+		t.Fatalf("Reconcile() = %v, %v, want a requeue", result, err)
+	}
+	if err := c.Get(ctx, req.NamespacedName, binding); err != nil {
+		t.Fatal(err)
+	}
+	if binding.Status.Destinations[0].RetryCount != 1 {
+		t.Errorf("retryCount = %d, want 1", binding.Status.Destinations[0].RetryCount)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := c.Get(ctx, req.NamespacedName, binding); !errors.IsNotFound(err) {
+		t.Errorf("binding should be removed after the last attempt, got %v", err)
+	}
+}
+
+func TestSourceSecretAllowed(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gatewayv1beta1.Install(scheme); err != nil {
+		t.Fatal(err)
+	}
+	grant := func(namespace, fromNamespace, kind string) *gatewayv1beta1.ReferenceGrant {
+		return &gatewayv1beta1.ReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: fromNamespace + "-" + kind, Namespace: namespace},
+			Spec: gatewayv1beta1.ReferenceGrantSpec{
+				From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1beta1.Group(certautov1.GroupVersion.Group), Kind: gatewayv1beta1.Kind(kind), Namespace: gatewayv1beta1.Namespace(fromNamespace)}},
+				To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Secret"}},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		grant("shared", "apps", "CertificateBinding"),
+		grant("other", "apps", "Certificate"),
+	).Build()
+	r := &CertificateBindingReconciler{Client: c, Log: logr.Discard(), Scheme: scheme}
+	binding := &certautov1.CertificateBinding{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"}}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      bool
+	}{
+		{name: "same namespace", namespace: "apps", want: true},
+		{name: "granted", namespace: "shared", want: true},
+		{name: "granted to another kind", namespace: "other", want: false},
+		{name: "no grant", namespace: "kube-system", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.sourceSecretAllowed(context.Background(), binding, types.NamespacedName{Name: "web-tls", Namespace: tt.namespace})
+			if err != nil {
+				t.Fatalf("sourceSecretAllowed() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("sourceSecretAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// GatewayReconciler generates CertificateBindings for the TLS secrets
+// referenced by listeners of Gateways annotated with certauto.sanorg.in/sync-to
+// or certauto.sanorg.in/reflect-to.
+type GatewayReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("gateway", req.NamespacedName)
+
+	var gateway gatewayv1.Gateway
+	if err := r.Get(ctx, req.NamespacedName, &gateway); err != nil {
+		if errors.IsNotFound(err) {
+			// Generated bindings are garbage collected through their owner reference
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var secrets []types.NamespacedName
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		for _, ref := range listener.TLS.CertificateRefs {
+			if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Secret") {
+				continue
+			}
+			secret := types.NamespacedName{Name: string(ref.Name), Namespace: gateway.Namespace}
+			if ref.Namespace != nil {
+				secret.Namespace = string(*ref.Namespace)
+			}
+			if containsSecret(secrets, secret) {
+				continue
+			}
+			// Like the Gateway itself, only follow cross-namespace references that are granted
+			if secret.Namespace != gateway.Namespace {
+				granted, err := referenceGranted(ctx, r.Client, gatewayv1.GroupName, "Gateway", gateway.Namespace, secret)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				if !granted {
+					log.Info("Skipping certificate reference not allowed by a ReferenceGrant", "secret", secret)
+					continue
+				}
+			}
+			secrets = append(secrets, secret)
+		}
+	}
+
+	if err := reconcileGeneratedBindings(ctrl.LoggerInto(ctx, log), r.Client, r.Scheme, &gateway, secrets); err != nil {
+		log.Error(err, "Failed to reconcile generated certificatebindings")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. It must only be
+// called when the Gateway API CRDs are installed.
+func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gatewayv1.Gateway{}).
+		Owns(&certautov1.CertificateBinding{}).
+		Watches(
+			&certautov1.DestinationProvider{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &gatewayv1.GatewayList{}, SyncToAnnotation, obj.GetName())
+			}),
+		).
+		Watches(
+			&gatewayv1beta1.ReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.mapReferenceGrantToGateways),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &gatewayv1.GatewayList{}, ReflectToAnnotation, obj.GetName())
+			}),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// mapReferenceGrantToGateways enqueues the Gateways in the namespaces a
+// ReferenceGrant allows, so that granted certificate references are picked up.
+func (r *GatewayReconciler) mapReferenceGrantToGateways(ctx context.Context, obj client.Object) []ctrl.Request {
+	grant, ok := obj.(*gatewayv1beta1.ReferenceGrant)
+	if !ok {
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, from := range grant.Spec.From {
+		if from.Group != gatewayv1.GroupName || from.Kind != "Gateway" {
+			continue
+		}
+		var gateways gatewayv1.GatewayList
+		if err := r.List(ctx, &gateways, client.InNamespace(string(from.Namespace))); err != nil {
+			continue
+		}
+		for _, gateway := range gateways.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&gateway)})
+		}
+	}
+	return requests
+}
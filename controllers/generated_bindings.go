@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// SyncToAnnotation lists the DestinationProviders that the TLS secrets of
+	// an annotated resource are synced to, separated by commas.
+	SyncToAnnotation = "certauto.sanorg.in/sync-to"
+
+	// ReflectToAnnotation lists namespaces that the TLS secrets of an annotated
+	// resource are reflected into, separated by commas.
+	ReflectToAnnotation = "certauto.sanorg.in/reflect-to"
+
+	// ReflectionAllowedLabel must be set to "true" on a namespace before the
+	// reflect-to annotation may copy secrets into it.
+	ReflectionAllowedLabel = "certauto.sanorg.in/reflection-allowed"
+
+	// GeneratedFromLabel is set on generated CertificateBindings to the kind of the owning resource.
+	GeneratedFromLabel = "certauto.sanorg.in/generated-from"
+)
+
+// +kubebuilder:rbac:groups=sanorg.in,resources=destinationproviders,verbs=get;list;watch
+
+// annotationValues parses the comma separated names of an annotation value.
+func annotationValues(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// providerDestinationRules builds a destination rule for every named
+// DestinationProvider. The rules reference the provider, so the binding
+// controller reads the config from the provider itself.
+func providerDestinationRules(ctx context.Context, c client.Client, names []string) ([]certautov1.DestinationRule, error) {
+	var rules []certautov1.DestinationRule
+	for _, name := range names {
+		provider := &certautov1.DestinationProvider{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, provider); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("destination provider %s does not exist", name)
+			}
+			return nil, fmt.Errorf("failed to get destination provider %s: %v", name, err)
+		}
+		rules = append(rules, certautov1.DestinationRule{
+			Name:     name,
+			Type:     provider.Spec.Type,
+			Config:   provider.Spec.Config,
+			Provider: name,
+		})
+	}
+	return rules, nil
+}
+
+// reflectionAllowedNamespaces returns the namespaces that opted in to
+// reflection with the ReflectionAllowedLabel. Other namespaces are skipped, so
+// that annotations cannot overwrite secrets in namespaces the owner of the
+// annotated resource has no access to.
+func reflectionAllowedNamespaces(ctx context.Context, c client.Client, names []string) ([]string, error) {
+	logger := log.FromContext(ctx)
+
+	var allowed []string
+	for _, name := range names {
+		namespace := &corev1.Namespace{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, namespace); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("Skipping reflection into missing namespace", "namespace", name)
+				continue
+			}
+			return nil, fmt.Errorf("failed to get namespace %s: %v", name, err)
+		}
+		if namespace.Labels[ReflectionAllowedLabel] != "true" {
+			logger.Info("Skipping reflection into namespace without the reflection-allowed label", "namespace", name)
+			continue
+		}
+		allowed = append(allowed, name)
+	}
+	return allowed, nil
+}
+
+// reflectDestinationRules builds a Kubernetes destination rule reflecting secret
+// into each of namespaces.
+func reflectDestinationRules(namespaces []string, secret types.NamespacedName) []certautov1.DestinationRule {
+	var rules []certautov1.DestinationRule
+	for _, namespace := range namespaces {
+		if namespace == secret.Namespace {
+			continue
+		}
+		rules = append(rules, certautov1.DestinationRule{
+			Name: "reflect-" + namespace,
+			Type: "Kubernetes",
+			Config: certautov1.DestinationConfig{
+				TargetNamespace:  namespace,
+				TargetSecretName: secret.Name,
+				// Reflected copies only exist because of the annotation
+				DeletionPolicy: certautov1.DeletionPolicyDelete,
+			},
+		})
+	}
+	return rules
+}
+
+// generatedBindingName returns the name of the binding generated for a TLS
+// secret of an annotated resource, <kind>-<owner>-<secret>[-<namespace>].
+// Names longer than an object name allows are truncated and suffixed with a
+// hash of the full name.
+func generatedBindingName(kind string, owner client.Object, secret types.NamespacedName) string {
+	name := fmt.Sprintf("%s-%s-%s", strings.ToLower(kind), owner.GetName(), secret.Name)
+	if secret.Namespace != owner.GetNamespace() {
+		name = fmt.Sprintf("%s-%s", name, secret.Namespace)
+	}
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:10]
+	return strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.") + suffix
+}
+
+// reconcileGeneratedBindings makes the CertificateBindings owned by owner match
+// its annotations: one binding per TLS secret, syncing to the annotated
+// DestinationProviders and reflecting into the annotated namespaces. Bindings
+// that are no longer wanted, for example because the annotations were removed,
+// are deleted.
+func reconcileGeneratedBindings(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, secrets []types.NamespacedName) error {
+	logger := log.FromContext(ctx)
+
+	gvk, err := apiutil.GVKForObject(owner, scheme)
+	if err != nil {
+		return err
+	}
+
+	providerRules, err := providerDestinationRules(ctx, c, annotationValues(owner.GetAnnotations()[SyncToAnnotation]))
+	if err != nil {
+		return err
+	}
+	reflectNamespaces, err := reflectionAllowedNamespaces(ctx, c, annotationValues(owner.GetAnnotations()[ReflectToAnnotation]))
+	if err != nil {
+		return err
+	}
+
+	desired := map[string][]certautov1.DestinationRule{}
+	sources := map[string]types.NamespacedName{}
+	for _, secret := range secrets {
+		rules := append(slices.Clone(providerRules), reflectDestinationRules(reflectNamespaces, secret)...)
+		if len(rules) == 0 {
+			continue
+		}
+		name := generatedBindingName(gvk.Kind, owner, secret)
+		desired[name] = rules
+		sources[name] = secret
+	}
+
+	for name, rules := range desired {
+		secret := sources[name]
+		binding := &certautov1.CertificateBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: owner.GetNamespace(),
+			},
+		}
+
+		result, err := controllerutil.CreateOrUpdate(ctx, c, binding, func() error {
+			if !binding.CreationTimestamp.IsZero() && !metav1.IsControlledBy(binding, owner) {
+				return fmt.Errorf("certificatebinding %s already exists and is not owned by %s %s", name, gvk.Kind, owner.GetName())
+			}
+			if binding.Labels == nil {
+				binding.Labels = map[string]string{}
+			}
+			binding.Labels["app.kubernetes.io/managed-by"] = "certauto"
+			binding.Labels[GeneratedFromLabel] = strings.ToLower(gvk.Kind)
+			binding.Spec.SourceSecretRef = &certautov1.SecretRef{Name: secret.Name, Namespace: secret.Namespace}
+			binding.Spec.DestinationRules = rules
+			return ctrl.SetControllerReference(owner, binding, scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync certificatebinding %s: %v", name, err)
+		}
+		logger.Info("Synced generated certificatebinding", "certificatebinding", name, "result", result)
+	}
+
+	var list certautov1.CertificateBindingList
+	if err := c.List(ctx, &list, client.InNamespace(owner.GetNamespace()), client.HasLabels{GeneratedFromLabel}); err != nil {
+		return fmt.Errorf("failed to list generated certificatebindings: %v", err)
+	}
+	for i := range list.Items {
+		binding := &list.Items[i]
+		if _, ok := desired[binding.Name]; ok || !metav1.IsControlledBy(binding, owner) {
+			continue
+		}
+		logger.Info("Deleting generated certificatebinding", "certificatebinding", binding.Name)
+		if err := c.Delete(ctx, binding); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete certificatebinding %s: %v", binding.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// annotatedObjects returns requests for the objects in list whose annotation
+// names value.
+func annotatedObjects(ctx context.Context, c client.Client, list client.ObjectList, annotation, value string) []ctrl.Request {
+	if err := c.List(ctx, list); err != nil {
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		if slices.Contains(annotationValues(obj.GetAnnotations()[annotation]), value) {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// IngressReconciler generates CertificateBindings for the TLS secrets of
+// Ingresses annotated with certauto.sanorg.in/sync-to or
+// certauto.sanorg.in/reflect-to.
+type IngressReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+
+func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("ingress", req.NamespacedName)
+
+	var ingress networkingv1.Ingress
+	if err := r.Get(ctx, req.NamespacedName, &ingress); err != nil {
+		if errors.IsNotFound(err) {
+			// Generated bindings are garbage collected through their owner reference
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var secrets []types.NamespacedName
+	for _, tls := range ingress.Spec.TLS {
+		secret := types.NamespacedName{Name: tls.SecretName, Namespace: ingress.Namespace}
+		if tls.SecretName != "" && !containsSecret(secrets, secret) {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	if err := reconcileGeneratedBindings(ctrl.LoggerInto(ctx, log), r.Client, r.Scheme, &ingress, secrets); err != nil {
+		log.Error(err, "Failed to reconcile generated certificatebindings")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1.Ingress{}).
+		Owns(&certautov1.CertificateBinding{}).
+		Watches(
+			&certautov1.DestinationProvider{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &networkingv1.IngressList{}, SyncToAnnotation, obj.GetName())
+			}),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &networkingv1.IngressList{}, ReflectToAnnotation, obj.GetName())
+			}),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+// containsSecret reports whether secrets contains secret.
+func containsSecret(secrets []types.NamespacedName, secret types.NamespacedName) bool {
+	for _, s := range secrets {
+		if s == secret {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// loadAWSConfig loads AWS credentials from the default chain, such as IRSA or
+// the instance profile, for the region of the destination.
+func loadAWSConfig(ctx context.Context, destConfig certautov1.DestinationConfig) (aws.Config, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(destConfig.Region))
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	return cfg, nil
+}
@@ -2,12 +2,16 @@ package plugins
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/acm"
 	"github.com/aws/aws-sdk-go-v2/service/acm/types"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -15,6 +19,10 @@ import (
 	certautov1 "github.com/sanmarg/certauto/api/v1"
 )
 
+// acmSourceTag is set on imported ACM certificates to the namespace and name of
+// the source secret, to recognise certificates replaced by a new import.
+const acmSourceTag = "certauto.sanorg.in/source"
+
 // AWSACMPlugin syncs certificates to AWS ACM.
 type AWSACMPlugin struct {
 	client.Client
@@ -27,63 +35,110 @@ func (p *AWSACMPlugin) Name() string {
 
 // Sync syncs the certificate to AWS ACM.
 func (p *AWSACMPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	_, err := p.SyncResource(ctx, secret, destConfig, "")
+	return err
+}
+
+// SyncResource re-imports the certificate into certificateArn, or the ARN
+// recorded from an earlier import, and imports a new certificate when neither
+// exists. It returns the ARN of the certificate. Certificates imported earlier
+// from the same secret are deleted once detached from the listeners.
+func (p *AWSACMPlugin) SyncResource(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig, certificateARN string) (string, error) {
 	logger := log.FromContext(ctx)
 
 	// 1. Load AWS config
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(destConfig.Region))
+	cfg, err := loadAWSConfig(ctx, destConfig)
 	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %v", err)
+		return "", err
 	}
 
 	// 2. Create ACM client
 	acmClient := acm.NewFromConfig(cfg)
 
 	// 3. Check if certificate exists
-	exists, err := p.CheckExists(ctx, destConfig)
+	certificateARN = defaultString(destConfig.CertificateARN, certificateARN)
+	exists, err := p.CheckResourceExists(ctx, destConfig, certificateARN)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// 4. Import or update certificate
-	certBytes := secret.Data["tls.crt"]
-	keyBytes := secret.Data["tls.key"]
-	chainBytes := secret.Data["ca.crt"]
-
-	if exists && destConfig.CertificateARN != "" {
+	if destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil {
+		return "", fmt.Errorf("ACM does not accept encrypted private keys")
+	}
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+	if err != nil {
+		return "", err
+	}
+	input := &acm.ImportCertificateInput{
+		Certificate:      secret.Data["tls.crt"],
+		PrivateKey:       keyBytes,
+		CertificateChain: secret.Data["ca.crt"],
+	}
+	if exists {
 		// Re-import certificate to update it
-		logger.Info("Importing certificate to ACM", "arn", destConfig.CertificateARN)
-		_, err = acmClient.ImportCertificate(ctx, &acm.ImportCertificateInput{
-			Certificate:      certBytes,
-			PrivateKey:       keyBytes,
-			CertificateChain: chainBytes,
-			CertificateArn:   aws.String(destConfig.CertificateARN),
-		})
+		logger.Info("Importing certificate to ACM", "arn", certificateARN)
+		input.CertificateArn = aws.String(certificateARN)
 	} else {
 		// Import new certificate
 		logger.Info("Importing new ACM certificate")
-		_, err = acmClient.ImportCertificate(ctx, &acm.ImportCertificateInput{
-			Certificate:      certBytes,
-			PrivateKey:       keyBytes,
-			CertificateChain: chainBytes,
-			Tags: []types.Tag{
-				{
-					Key:   aws.String("ManagedBy"),
-					Value: aws.String("certauto"),
-				},
+		input.Tags = []types.Tag{
+			{
+				Key:   aws.String("ManagedBy"),
+				Value: aws.String("certauto"),
 			},
-		})
+			{
+				Key:   aws.String(acmSourceTag),
+				Value: aws.String(acmSource(secret)),
+			},
+		}
+	}
+	imported, err := acmClient.ImportCertificate(ctx, input)
+	if err != nil {
+		return "", err
 	}
+	certificateARN = aws.ToString(imported.CertificateArn)
 
-	return err
+	// 5. Serve the certificate from the configured listeners
+	if len(destConfig.Listeners) == 0 {
+		return certificateARN, nil
+	}
+	replaced, err := attachListenerCertificates(ctx, elbv2.NewFromConfig(cfg), acmClient, destConfig.Listeners, certificateARN, acmSource(secret))
+	if err != nil {
+		return certificateARN, err
+	}
+
+	// 6. Delete the replaced certificates, unless still in use elsewhere
+	for _, arn := range replaced {
+		logger.Info("Deleting replaced ACM certificate", "arn", arn)
+		_, err := acmClient.DeleteCertificate(ctx, &acm.DeleteCertificateInput{CertificateArn: aws.String(arn)})
+		var notFound *types.ResourceNotFoundException
+		var inUse *types.ResourceInUseException
+		switch {
+		case errors.As(err, &notFound):
+		case errors.As(err, &inUse):
+			logger.Info("Replaced ACM certificate is still in use", "arn", arn)
+		case err != nil:
+			return certificateARN, fmt.Errorf("failed to delete replaced certificate %s: %v", arn, err)
+		}
+	}
+	return certificateARN, nil
 }
 
 // CheckExists checks if the certificate exists in ACM.
 func (p *AWSACMPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
-	if destConfig.CertificateARN == "" {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the certificate exists in ACM, using the
+// recorded ARN unless certificateArn is set.
+func (p *AWSACMPlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, certificateARN string) (bool, error) {
+	certificateARN = defaultString(destConfig.CertificateARN, certificateARN)
+	if certificateARN == "" {
 		return false, nil
 	}
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(destConfig.Region))
+	cfg, err := loadAWSConfig(ctx, destConfig)
 	if err != nil {
 		return false, err
 	}
@@ -91,34 +146,202 @@ func (p *AWSACMPlugin) CheckExists(ctx context.Context, destConfig certautov1.De
 	acmClient := acm.NewFromConfig(cfg)
 
 	_, err = acmClient.DescribeCertificate(ctx, &acm.DescribeCertificateInput{
-		CertificateArn: aws.String(destConfig.CertificateARN),
+		CertificateArn: aws.String(certificateARN),
 	})
-	if err != nil {
-		// Check if it's a not found error
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
 		return false, nil
 	}
+	if err != nil {
+		return false, fmt.Errorf("failed to describe certificate %s: %v", certificateARN, err)
+	}
 	return true, nil
 }
 
 // Delete deletes the certificate from ACM.
 func (p *AWSACMPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
-	if destConfig.CertificateARN == "" {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource deletes the certificate from ACM, using the recorded ARN
+// unless certificateArn is set. The certificate is removed from the configured
+// listeners first; a certificate still in use elsewhere is left in place.
+func (p *AWSACMPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, certificateARN string) error {
+	logger := log.FromContext(ctx)
+
+	certificateARN = defaultString(destConfig.CertificateARN, certificateARN)
+	if certificateARN == "" {
 		return nil
 	}
 
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(destConfig.Region))
+	cfg, err := loadAWSConfig(ctx, destConfig)
 	if err != nil {
 		return err
 	}
 
 	acmClient := acm.NewFromConfig(cfg)
 
+	if len(destConfig.Listeners) > 0 {
+		if err := detachListenerCertificate(ctx, elbv2.NewFromConfig(cfg), destConfig.Listeners, certificateARN); err != nil {
+			return err
+		}
+	}
+
 	_, err = acmClient.DeleteCertificate(ctx, &acm.DeleteCertificateInput{
-		CertificateArn: aws.String(destConfig.CertificateARN),
+		CertificateArn: aws.String(certificateARN),
 	})
+	var notFound *types.ResourceNotFoundException
+	var inUse *types.ResourceInUseException
+	switch {
+	case errors.As(err, &notFound):
+		return nil
+	case errors.As(err, &inUse):
+		logger.Info("ACM certificate is still in use, leaving it in place", "arn", certificateARN)
+		return nil
+	}
 	return err
 }
 
+// detachListenerCertificate removes the certificate from the SNI certificates of
+// the listeners. A default certificate cannot be removed without replacing it,
+// so it stays attached and keeps the certificate in use.
+func detachListenerCertificate(ctx context.Context, elbClient *elbv2.Client, listeners []certautov1.LoadBalancerListener, certificateARN string) error {
+	logger := log.FromContext(ctx)
+
+	for _, listener := range listeners {
+		if listener.Default {
+			continue
+		}
+		logger.Info("Removing certificate from listener", "listener", listener.ARN, "arn", certificateARN)
+		_, err := elbClient.RemoveListenerCertificates(ctx, &elbv2.RemoveListenerCertificatesInput{
+			ListenerArn:  aws.String(listener.ARN),
+			Certificates: []elbv2types.Certificate{{CertificateArn: aws.String(certificateARN)}},
+		})
+		var listenerNotFound *elbv2types.ListenerNotFoundException
+		if err != nil && !errors.As(err, &listenerNotFound) {
+			return fmt.Errorf("failed to remove certificate from listener %s: %v", listener.ARN, err)
+		}
+	}
+	return nil
+}
+
+// attachListenerCertificates makes sure each listener serves the certificate,
+// as its default certificate or through SNI, and removes certificates that were
+// imported earlier from the same source secret. It returns the removed certificates.
+func attachListenerCertificates(ctx context.Context, elbClient *elbv2.Client, acmClient *acm.Client, listeners []certautov1.LoadBalancerListener, certificateARN, source string) ([]string, error) {
+	logger := log.FromContext(ctx)
+
+	// Tags of the certificates found on the listeners, shared across listeners
+	sources := map[string]string{}
+	sourceOf := func(arn string) (string, error) {
+		if value, ok := sources[arn]; ok {
+			return value, nil
+		}
+		// Only ACM certificates carry our tag
+		if !strings.Contains(arn, ":acm:") {
+			sources[arn] = ""
+			return "", nil
+		}
+		tags, err := acmClient.ListTagsForCertificate(ctx, &acm.ListTagsForCertificateInput{CertificateArn: aws.String(arn)})
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			sources[arn] = ""
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags of certificate %s: %v", arn, err)
+		}
+		for _, tag := range tags.Tags {
+			if aws.ToString(tag.Key) == acmSourceTag {
+				sources[arn] = aws.ToString(tag.Value)
+			}
+		}
+		return sources[arn], nil
+	}
+
+	var replaced []string
+	for _, listener := range listeners {
+		var certificates []elbv2types.Certificate
+		paginator := elbv2.NewDescribeListenerCertificatesPaginator(elbClient, &elbv2.DescribeListenerCertificatesInput{
+			ListenerArn: aws.String(listener.ARN),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe certificates of listener %s: %v", listener.ARN, err)
+			}
+			certificates = append(certificates, page.Certificates...)
+		}
+
+		isDefault, isSNI := false, false
+		var previous []elbv2types.Certificate
+		for _, certificate := range certificates {
+			arn := aws.ToString(certificate.CertificateArn)
+			if arn == certificateARN {
+				if aws.ToBool(certificate.IsDefault) {
+					isDefault = true
+				} else {
+					isSNI = true
+				}
+				continue
+			}
+			if aws.ToBool(certificate.IsDefault) && !listener.Default {
+				continue
+			}
+			certSource, err := sourceOf(arn)
+			if err != nil {
+				return nil, err
+			}
+			if certSource != source {
+				continue
+			}
+			if !slices.Contains(replaced, arn) {
+				replaced = append(replaced, arn)
+			}
+			// A replaced default certificate is detached by setting the new one
+			if !aws.ToBool(certificate.IsDefault) {
+				previous = append(previous, elbv2types.Certificate{CertificateArn: aws.String(arn)})
+			}
+		}
+
+		switch {
+		case listener.Default && !isDefault:
+			logger.Info("Setting default certificate of listener", "listener", listener.ARN, "arn", certificateARN)
+			if _, err := elbClient.ModifyListener(ctx, &elbv2.ModifyListenerInput{
+				ListenerArn:  aws.String(listener.ARN),
+				Certificates: []elbv2types.Certificate{{CertificateArn: aws.String(certificateARN)}},
+			}); err != nil {
+				return nil, fmt.Errorf("failed to set default certificate of listener %s: %v", listener.ARN, err)
+			}
+		case !listener.Default && !isSNI:
+			logger.Info("Adding certificate to listener", "listener", listener.ARN, "arn", certificateARN)
+			if _, err := elbClient.AddListenerCertificates(ctx, &elbv2.AddListenerCertificatesInput{
+				ListenerArn:  aws.String(listener.ARN),
+				Certificates: []elbv2types.Certificate{{CertificateArn: aws.String(certificateARN)}},
+			}); err != nil {
+				return nil, fmt.Errorf("failed to add certificate to listener %s: %v", listener.ARN, err)
+			}
+		}
+
+		if len(previous) > 0 {
+			logger.Info("Removing replaced certificates from listener", "listener", listener.ARN, "count", len(previous))
+			if _, err := elbClient.RemoveListenerCertificates(ctx, &elbv2.RemoveListenerCertificatesInput{
+				ListenerArn:  aws.String(listener.ARN),
+				Certificates: previous,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to remove certificates from listener %s: %v", listener.ARN, err)
+			}
+		}
+	}
+
+	return replaced, nil
+}
+
+// acmSource returns the value of the source tag for certificates imported from secret.
+func acmSource(secret *corev1.Secret) string {
+	return secret.Namespace + "/" + secret.Name
+}
+
 // getDomainFromSecret extracts the domain from the TLS certificate.
 func getDomainFromSecret(secret *corev1.Secret) string {
 	// TODO: Parse the certificate to extract the domain
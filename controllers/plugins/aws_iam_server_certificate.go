@@ -0,0 +1,332 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cloudfronttypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// defaultServerCertificateGracePeriod is how long superseded IAM server certificates are kept by default.
+const defaultServerCertificateGracePeriod = 24 * time.Hour
+
+// AWSIAMServerCertificatePlugin uploads certificates as IAM server
+// certificates for Classic Load Balancers and CloudFront distributions that
+// cannot use ACM. IAM server certificates are immutable, so every certificate
+// is uploaded under a new name, the configured listeners and distributions are
+// moved to it and superseded certificates are deleted after a grace period.
+type AWSIAMServerCertificatePlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *AWSIAMServerCertificatePlugin) Name() string {
+	return "AWSIAMServerCertificate"
+}
+
+// Sync uploads the certificate if needed, points the configured references at
+// it and deletes superseded certificates whose grace period has passed.
+func (p *AWSIAMServerCertificatePlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	serverCert := destConfig.IAMServerCertificate
+	if serverCert == nil || serverCert.Name == "" {
+		return fmt.Errorf("iamServerCertificate name is required for AWSIAMServerCertificate destination")
+	}
+	certPath := serverCertificatePath(serverCert)
+	if len(serverCert.CloudFrontDistributionIDs) > 0 && !strings.HasPrefix(certPath, "/cloudfront/") {
+		return fmt.Errorf("iamServerCertificate path must start with /cloudfront/ to be used by CloudFront")
+	}
+	if destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil {
+		return fmt.Errorf("IAM does not accept encrypted private keys")
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadIAMConfig(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	iamClient := iam.NewFromConfig(cfg)
+
+	// 1. Upload the certificate unless this generation exists already
+	name := fmt.Sprintf("%s-%s", serverCert.Name, certificateFingerprint(bundle.Leaf)[:10])
+	var current *iamtypes.ServerCertificateMetadata
+	existing, err := iamClient.GetServerCertificate(ctx, &iam.GetServerCertificateInput{ServerCertificateName: aws.String(name)})
+	var noSuchEntity *iamtypes.NoSuchEntityException
+	switch {
+	case errors.As(err, &noSuchEntity):
+		keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+		if err != nil {
+			return err
+		}
+		input := &iam.UploadServerCertificateInput{
+			ServerCertificateName: aws.String(name),
+			Path:                  aws.String(certPath),
+			CertificateBody:       aws.String(string(encodeCertificatesPEM(bundle.Chain()[:1]))),
+			PrivateKey:            aws.String(string(keyBytes)),
+			Tags: []iamtypes.Tag{
+				{Key: aws.String("ManagedBy"), Value: aws.String("certauto")},
+			},
+		}
+		if chain := bundle.FullChain()[1:]; len(chain) > 0 {
+			input.CertificateChain = aws.String(string(encodeCertificatesPEM(chain)))
+		}
+		logger.Info("Uploading IAM server certificate", "serverCertificate", name)
+		uploaded, err := iamClient.UploadServerCertificate(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to upload server certificate: %v", err)
+		}
+		current = uploaded.ServerCertificateMetadata
+	case err != nil:
+		return fmt.Errorf("failed to get server certificate: %v", err)
+	default:
+		current = existing.ServerCertificate.ServerCertificateMetadata
+	}
+
+	// 2. Move the references to the current certificate
+	if err := swapLoadBalancerCertificates(ctx, elb.NewFromConfig(cfg), serverCert.LoadBalancerListeners, aws.ToString(current.Arn)); err != nil {
+		return err
+	}
+	if err := swapCloudFrontCertificates(ctx, cloudfront.NewFromConfig(cfg), serverCert.CloudFrontDistributionIDs, aws.ToString(current.ServerCertificateId)); err != nil {
+		return err
+	}
+
+	// 3. Delete superseded certificates
+	gracePeriod := defaultServerCertificateGracePeriod
+	if serverCert.GracePeriod != nil {
+		gracePeriod = serverCert.GracePeriod.Duration
+	}
+	return pruneServerCertificates(ctx, iamClient, serverCert, name, gracePeriod)
+}
+
+// CheckExists checks if any generation of the server certificate exists.
+func (p *AWSIAMServerCertificatePlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	if destConfig.IAMServerCertificate == nil {
+		return false, nil
+	}
+
+	cfg, err := loadIAMConfig(ctx, destConfig)
+	if err != nil {
+		return false, err
+	}
+
+	generations, err := listServerCertificates(ctx, iam.NewFromConfig(cfg), destConfig.IAMServerCertificate)
+	if err != nil {
+		return false, err
+	}
+	return len(generations) > 0, nil
+}
+
+// Delete deletes every generation of the server certificate. Generations still
+// referenced by a load balancer or distribution are left in place.
+func (p *AWSIAMServerCertificatePlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	if destConfig.IAMServerCertificate == nil {
+		return nil
+	}
+
+	cfg, err := loadIAMConfig(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	iamClient := iam.NewFromConfig(cfg)
+
+	generations, err := listServerCertificates(ctx, iamClient, destConfig.IAMServerCertificate)
+	if err != nil {
+		return err
+	}
+	for _, generation := range generations {
+		_, err := iamClient.DeleteServerCertificate(ctx, &iam.DeleteServerCertificateInput{
+			ServerCertificateName: generation.ServerCertificateName,
+		})
+		var conflict *iamtypes.DeleteConflictException
+		if errors.As(err, &conflict) {
+			logger.Info("IAM server certificate is still in use, leaving it in place",
+				"serverCertificate", aws.ToString(generation.ServerCertificateName))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete server certificate %s: %v", aws.ToString(generation.ServerCertificateName), err)
+		}
+	}
+	return nil
+}
+
+// loadIAMConfig loads the AWS config, defaulting the region to us-east-1 as
+// IAM and CloudFront are global services.
+func loadIAMConfig(ctx context.Context, destConfig certautov1.DestinationConfig) (aws.Config, error) {
+	cfg, err := loadAWSConfig(ctx, destConfig)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return cfg, nil
+}
+
+// swapLoadBalancerCertificates points the Classic Load Balancer listeners at the server certificate.
+func swapLoadBalancerCertificates(ctx context.Context, elbClient *elb.Client, listeners []certautov1.ClassicLoadBalancerListener, arn string) error {
+	logger := log.FromContext(ctx)
+
+	for _, listener := range listeners {
+		described, err := elbClient.DescribeLoadBalancers(ctx, &elb.DescribeLoadBalancersInput{
+			LoadBalancerNames: []string{listener.LoadBalancerName},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe load balancer %s: %v", listener.LoadBalancerName, err)
+		}
+
+		found := false
+		for _, lb := range described.LoadBalancerDescriptions {
+			for _, desc := range lb.ListenerDescriptions {
+				if desc.Listener == nil || desc.Listener.LoadBalancerPort != listener.Port {
+					continue
+				}
+				found = true
+				if aws.ToString(desc.Listener.SSLCertificateId) == arn {
+					continue
+				}
+				logger.Info("Updating load balancer listener certificate",
+					"loadBalancer", listener.LoadBalancerName, "port", listener.Port)
+				if _, err := elbClient.SetLoadBalancerListenerSSLCertificate(ctx, &elb.SetLoadBalancerListenerSSLCertificateInput{
+					LoadBalancerName: aws.String(listener.LoadBalancerName),
+					LoadBalancerPort: listener.Port,
+					SSLCertificateId: aws.String(arn),
+				}); err != nil {
+					return fmt.Errorf("failed to update listener %s:%d: %v", listener.LoadBalancerName, listener.Port, err)
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("load balancer %s has no listener on port %d", listener.LoadBalancerName, listener.Port)
+		}
+	}
+	return nil
+}
+
+// swapCloudFrontCertificates points the CloudFront distributions at the server certificate.
+func swapCloudFrontCertificates(ctx context.Context, cfClient *cloudfront.Client, distributionIDs []string, certificateID string) error {
+	logger := log.FromContext(ctx)
+
+	for _, id := range distributionIDs {
+		config, err := cfClient.GetDistributionConfig(ctx, &cloudfront.GetDistributionConfigInput{Id: aws.String(id)})
+		if err != nil {
+			return fmt.Errorf("failed to get distribution %s: %v", id, err)
+		}
+
+		viewerCert := config.DistributionConfig.ViewerCertificate
+		if viewerCert != nil && aws.ToString(viewerCert.IAMCertificateId) == certificateID {
+			continue
+		}
+		if viewerCert == nil {
+			viewerCert = &cloudfronttypes.ViewerCertificate{}
+		}
+		viewerCert.IAMCertificateId = aws.String(certificateID)
+		viewerCert.ACMCertificateArn = nil
+		viewerCert.CloudFrontDefaultCertificate = nil
+		viewerCert.Certificate = nil
+		viewerCert.CertificateSource = ""
+		if viewerCert.SSLSupportMethod == "" {
+			viewerCert.SSLSupportMethod = cloudfronttypes.SSLSupportMethodSniOnly
+		}
+		config.DistributionConfig.ViewerCertificate = viewerCert
+
+		logger.Info("Updating CloudFront distribution certificate", "distribution", id)
+		if _, err := cfClient.UpdateDistribution(ctx, &cloudfront.UpdateDistributionInput{
+			Id:                 aws.String(id),
+			IfMatch:            config.ETag,
+			DistributionConfig: config.DistributionConfig,
+		}); err != nil {
+			return fmt.Errorf("failed to update distribution %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// pruneServerCertificates deletes generations other than current that were
+// superseded, that is a newer generation was uploaded, longer than gracePeriod
+// ago. Certificates that are still in use elsewhere are kept.
+func pruneServerCertificates(ctx context.Context, iamClient *iam.Client, serverCert *certautov1.IAMServerCertificate, current string, gracePeriod time.Duration) error {
+	logger := log.FromContext(ctx)
+
+	generations, err := listServerCertificates(ctx, iamClient, serverCert)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(generations, func(a, b iamtypes.ServerCertificateMetadata) int {
+		return aws.ToTime(a.UploadDate).Compare(aws.ToTime(b.UploadDate))
+	})
+
+	for i, generation := range generations {
+		if aws.ToString(generation.ServerCertificateName) == current || i == len(generations)-1 {
+			continue
+		}
+		supersededAt := aws.ToTime(generations[i+1].UploadDate)
+		if time.Since(supersededAt) < gracePeriod {
+			continue
+		}
+
+		logger.Info("Deleting superseded IAM server certificate", "serverCertificate", aws.ToString(generation.ServerCertificateName))
+		_, err := iamClient.DeleteServerCertificate(ctx, &iam.DeleteServerCertificateInput{
+			ServerCertificateName: generation.ServerCertificateName,
+		})
+		var conflict *iamtypes.DeleteConflictException
+		if errors.As(err, &conflict) {
+			logger.Info("Superseded IAM server certificate is still in use",
+				"serverCertificate", aws.ToString(generation.ServerCertificateName))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete server certificate %s: %v", aws.ToString(generation.ServerCertificateName), err)
+		}
+	}
+	return nil
+}
+
+// listServerCertificates returns the generations of the server certificate.
+func listServerCertificates(ctx context.Context, iamClient *iam.Client, serverCert *certautov1.IAMServerCertificate) ([]iamtypes.ServerCertificateMetadata, error) {
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(serverCert.Name) + "-[0-9a-f]{10}$")
+
+	var generations []iamtypes.ServerCertificateMetadata
+	paginator := iam.NewListServerCertificatesPaginator(iamClient, &iam.ListServerCertificatesInput{
+		PathPrefix: aws.String(serverCertificatePath(serverCert)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list server certificates: %v", err)
+		}
+		for _, metadata := range page.ServerCertificateMetadataList {
+			if pattern.MatchString(aws.ToString(metadata.ServerCertificateName)) {
+				generations = append(generations, metadata)
+			}
+		}
+	}
+	return generations, nil
+}
+
+// serverCertificatePath returns the IAM path of the server certificates.
+func serverCertificatePath(serverCert *certautov1.IAMServerCertificate) string {
+	return defaultString(serverCert.Path, "/")
+}
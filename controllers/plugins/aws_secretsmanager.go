@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// AWSSecretsManagerPlugin stores certificates as JSON secrets in AWS Secrets
+// Manager, for workloads such as Lambda functions and ECS tasks that need the
+// private key and therefore cannot use ACM certificates.
+type AWSSecretsManagerPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *AWSSecretsManagerPlugin) Name() string {
+	return "AWSSecretsManager"
+}
+
+// ResourceName returns the name of the secret, which defaults to
+// <namespace>-<name> of the source secret.
+func (p *AWSSecretsManagerPlugin) ResourceName(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	if destConfig.CertificateName != "" {
+		return destConfig.CertificateName
+	}
+	return generateCertName(secret)
+}
+
+// Sync creates the secret or puts a new value when the certificate changed,
+// and keeps its KMS key and tags up to date.
+func (p *AWSSecretsManagerPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	cfg, err := loadAWSConfig(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	smClient := secretsmanager.NewFromConfig(cfg)
+
+	secretID := p.ResourceName(secret, destConfig)
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+	fields := secretFieldNames(destConfig.SecretFields)
+	if fields.Certificate == fields.PrivateKey || fields.Certificate == fields.Chain || fields.PrivateKey == fields.Chain {
+		return fmt.Errorf("secretFields must be distinct")
+	}
+	tags := []types.Tag{
+		{Key: aws.String("ManagedBy"), Value: aws.String("certauto")},
+	}
+	for _, key := range slices.Sorted(maps.Keys(destConfig.Tags)) {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(destConfig.Tags[key])})
+	}
+
+	described, err := smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(secretID)})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		value, err := secretsManagerValue(ctx, p.Client, secret, bundle, destConfig, fields, nil)
+		if err != nil {
+			return err
+		}
+		logger.Info("Creating Secrets Manager secret", "secret", secretID)
+		input := &secretsmanager.CreateSecretInput{
+			Name:         aws.String(secretID),
+			Description:  aws.String(fmt.Sprintf("TLS certificate synced by certauto from %s/%s", secret.Namespace, secret.Name)),
+			SecretString: aws.String(value),
+			Tags:         tags,
+		}
+		if destConfig.KMSKeyID != "" {
+			input.KmsKeyId = aws.String(destConfig.KMSKeyID)
+		}
+		if _, err := smClient.CreateSecret(ctx, input); err != nil {
+			return fmt.Errorf("failed to create secret: %v", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to describe secret: %v", err)
+	}
+
+	// A secret scheduled for deletion rejects new values until it is restored
+	if described.DeletedDate != nil {
+		logger.Info("Restoring Secrets Manager secret scheduled for deletion", "secret", secretID)
+		if _, err := smClient.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{SecretId: aws.String(secretID)}); err != nil {
+			return fmt.Errorf("failed to restore secret: %v", err)
+		}
+	}
+
+	if destConfig.KMSKeyID != "" && aws.ToString(described.KmsKeyId) != destConfig.KMSKeyID {
+		logger.Info("Updating Secrets Manager secret KMS key", "secret", secretID)
+		if _, err := smClient.UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{
+			SecretId: aws.String(secretID),
+			KmsKeyId: aws.String(destConfig.KMSKeyID),
+		}); err != nil {
+			return fmt.Errorf("failed to update secret KMS key: %v", err)
+		}
+	}
+
+	var current map[string]string
+	currentValue, err := smClient.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to get secret value: %v", err)
+	}
+	if currentValue != nil && currentValue.SecretString != nil {
+		// A value that is not JSON written by us is simply replaced
+		_ = json.Unmarshal([]byte(*currentValue.SecretString), &current)
+	}
+
+	value, err := secretsManagerValue(ctx, p.Client, secret, bundle, destConfig, fields, current)
+	if err != nil {
+		return err
+	}
+	if currentValue == nil || aws.ToString(currentValue.SecretString) != value {
+		logger.Info("Putting Secrets Manager secret value", "secret", secretID)
+		if _, err := smClient.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String(secretID),
+			SecretString: aws.String(value),
+		}); err != nil {
+			return fmt.Errorf("failed to put secret value: %v", err)
+		}
+	}
+
+	if _, err := smClient.TagResource(ctx, &secretsmanager.TagResourceInput{
+		SecretId: aws.String(secretID),
+		Tags:     tags,
+	}); err != nil {
+		return fmt.Errorf("failed to tag secret: %v", err)
+	}
+
+	return nil
+}
+
+// CheckExists checks if the secret named by certificateName exists and is not
+// scheduled for deletion.
+func (p *AWSSecretsManagerPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the secret exists and is not scheduled for
+// deletion. The name defaults to certificateName.
+func (p *AWSSecretsManagerPlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, secretID string) (bool, error) {
+	secretID = defaultString(secretID, destConfig.CertificateName)
+	if secretID == "" {
+		return false, nil
+	}
+
+	cfg, err := loadAWSConfig(ctx, destConfig)
+	if err != nil {
+		return false, err
+	}
+	smClient := secretsmanager.NewFromConfig(cfg)
+
+	described, err := smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(secretID),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return described.DeletedDate == nil, nil
+}
+
+// Delete schedules the secret named by certificateName for deletion.
+func (p *AWSSecretsManagerPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource schedules the secret for deletion after the default recovery
+// window. The name defaults to certificateName.
+func (p *AWSSecretsManagerPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, secretID string) error {
+	secretID = defaultString(secretID, destConfig.CertificateName)
+	if secretID == "" {
+		return nil
+	}
+
+	cfg, err := loadAWSConfig(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	smClient := secretsmanager.NewFromConfig(cfg)
+
+	log.FromContext(ctx).Info("Deleting Secrets Manager secret", "secret", secretID)
+	_, err = smClient.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId: aws.String(secretID),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// secretsManagerValue returns the JSON secret value holding the leaf
+// certificate, private key and chain. current is the decoded value already
+// stored, used to keep an encrypted private key stable.
+func secretsManagerValue(ctx context.Context, c client.Reader, secret *corev1.Secret, bundle *certificateBundle, destConfig certautov1.DestinationConfig, fields certautov1.SecretFields, current map[string]string) (string, error) {
+	var existingKey []byte
+	if key, ok := current[fields.PrivateKey]; ok {
+		existingKey = []byte(key)
+	}
+	keyBytes, err := encodePrivateKey(ctx, c, secret, destConfig.PrivateKey, existingKey)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(map[string]string{
+		fields.Certificate: string(encodeCertificatesPEM(bundle.Chain()[:1])),
+		fields.PrivateKey:  string(keyBytes),
+		fields.Chain:       string(encodeCertificatesPEM(bundle.FullChain()[1:])),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret value: %v", err)
+	}
+	return string(value), nil
+}
+
+// secretFieldNames returns the configured JSON field names with defaults applied.
+func secretFieldNames(fields *certautov1.SecretFields) certautov1.SecretFields {
+	names := certautov1.SecretFields{
+		Certificate: "certificate",
+		PrivateKey:  "private_key",
+		Chain:       "certificate_chain",
+	}
+	if fields != nil {
+		names.Certificate = defaultString(fields.Certificate, names.Certificate)
+		names.PrivateKey = defaultString(fields.PrivateKey, names.PrivateKey)
+		names.Chain = defaultString(fields.Chain, names.Chain)
+	}
+	return names
+}
@@ -56,12 +56,29 @@ func (p *AzureKeyVaultPlugin) Sync(ctx context.Context, secret *corev1.Secret, d
 
 	// 5. Prepare certificate data (combine cert and key into PFX/PEM format)
 	certBytes := secret.Data["tls.crt"]
-	keyBytes := secret.Data["tls.key"]
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+	if err != nil {
+		return err
+	}
 
 	// Combine cert and key for import (base64 encoded PEM)
-	combinedPEM := append(certBytes, keyBytes...)
+	combinedPEM := append(append([]byte{}, certBytes...), keyBytes...)
 	base64Cert := base64.StdEncoding.EncodeToString(combinedPEM)
 
+	importParams := azcertificates.ImportCertificateParameters{
+		Base64EncodedCertificate: &base64Cert,
+	}
+
+	// Pass the passphrase along when the key is encrypted
+	if destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil {
+		passphrase, err := readSecretKeyRef(ctx, p.Client, destConfig.PrivateKey.PassphraseSecretRef)
+		if err != nil {
+			return fmt.Errorf("failed to read private key passphrase: %v", err)
+		}
+		password := string(passphrase)
+		importParams.Password = &password
+	}
+
 	// 6. Import certificate
 	if exists {
 		logger.Info("Importing new certificate version", "certificate", certName)
@@ -69,9 +86,7 @@ func (p *AzureKeyVaultPlugin) Sync(ctx context.Context, secret *corev1.Secret, d
 		logger.Info("Creating new certificate", "certificate", certName)
 	}
 
-	_, err = certClient.ImportCertificate(ctx, certName, azcertificates.ImportCertificateParameters{
-		Base64EncodedCertificate: &base64Cert,
-	}, nil)
+	_, err = certClient.ImportCertificate(ctx, certName, importParams, nil)
 
 	return err
 }
@@ -0,0 +1,178 @@
+package plugins
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// InjectCAFromSecretAnnotation must be set on injection targets to the
+// <namespace>/<name> of the source secret whose CA they accept, like
+// cert-manager's cert-manager.io/inject-ca-from-secret annotation.
+const InjectCAFromSecretAnnotation = "certauto.sanorg.in/inject-ca-from-secret"
+
+// caInjectionKinds maps the supported injection target kinds to their group versions.
+var caInjectionKinds = map[string]schema.GroupVersionKind{
+	"ValidatingWebhookConfiguration": {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"},
+	"MutatingWebhookConfiguration":   {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+	"APIService":                     {Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"},
+	"CustomResourceDefinition":       {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+}
+
+// CAInjectionPlugin injects the CA of a certificate into the caBundle fields of
+// webhook configurations, APIServices and CRD conversion webhooks, similar to
+// cert-manager's cainjector. Only targets annotated with
+// InjectCAFromSecretAnnotation naming the source secret are patched.
+type CAInjectionPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *CAInjectionPlugin) Name() string {
+	return "CAInjection"
+}
+
+// Sync sets the caBundle of every injection target to the CA of the source secret.
+func (p *CAInjectionPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	if len(destConfig.InjectionTargets) == 0 {
+		return fmt.Errorf("injectionTargets is required for CAInjection destination")
+	}
+
+	caBundle, err := injectableCA(secret)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(caBundle)
+	source := secret.Namespace + "/" + secret.Name
+
+	for _, target := range destConfig.InjectionTargets {
+		obj, err := p.getTarget(ctx, target)
+		if err != nil {
+			return err
+		}
+		if obj.GetAnnotations()[InjectCAFromSecretAnnotation] != source {
+			return fmt.Errorf("%s %s does not accept the CA of %s, set the %s annotation to opt in", target.Kind, target.Name, source, InjectCAFromSecretAnnotation)
+		}
+
+		original := obj.DeepCopy()
+		if err := setCABundle(obj, encoded); err != nil {
+			return fmt.Errorf("failed to inject CA into %s %s: %v", target.Kind, target.Name, err)
+		}
+
+		if equality.Semantic.DeepEqual(original.Object, obj.Object) {
+			logger.Info("CA bundle unchanged, skipping update", "kind", target.Kind, "name", target.Name)
+			continue
+		}
+
+		logger.Info("Injecting CA bundle", "kind", target.Kind, "name", target.Name)
+		if err := p.Patch(ctx, obj, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+			return fmt.Errorf("failed to patch %s %s: %v", target.Kind, target.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckExists checks if every injection target exists.
+func (p *CAInjectionPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	if len(destConfig.InjectionTargets) == 0 {
+		return false, nil
+	}
+
+	for _, target := range destConfig.InjectionTargets {
+		if _, err := p.getTarget(ctx, target); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Delete leaves the injection targets untouched. Their caBundle is still needed
+// by the API server for as long as the serving certificate is in use, and the
+// targets themselves are not owned by certauto.
+func (p *CAInjectionPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return nil
+}
+
+// getTarget fetches an injection target as an unstructured object.
+func (p *CAInjectionPlugin) getTarget(ctx context.Context, target certautov1.CAInjectionTarget) (*unstructured.Unstructured, error) {
+	gvk, ok := caInjectionKinds[target.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported injection target kind %s", target.Kind)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := p.Get(ctx, types.NamespacedName{Name: target.Name}, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get %s %s: %v", target.Kind, target.Name, err)
+	}
+	return obj, nil
+}
+
+// injectableCA returns the CA certificates that should be injected for a TLS secret.
+func injectableCA(secret *corev1.Secret) ([]byte, error) {
+	if ca := secret.Data["ca.crt"]; len(ca) > 0 {
+		return ca, nil
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return nil, err
+	}
+	cas := bundle.TrustAnchors()
+	if len(cas) == 0 {
+		return nil, fmt.Errorf("source secret has no CA certificates")
+	}
+	return encodeCertificatesPEM(cas), nil
+}
+
+// setCABundle sets the base64 encoded caBundle on obj according to its kind.
+func setCABundle(obj *unstructured.Unstructured, caBundle string) error {
+	switch obj.GetKind() {
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+		if err != nil {
+			return err
+		}
+		for i, webhook := range webhooks {
+			w, ok := webhook.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unexpected webhook entry %d", i)
+			}
+			if err := unstructured.SetNestedField(w, caBundle, "clientConfig", "caBundle"); err != nil {
+				return err
+			}
+			webhooks[i] = w
+		}
+		return unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks")
+	case "APIService":
+		return unstructured.SetNestedField(obj.Object, caBundle, "spec", "caBundle")
+	case "CustomResourceDefinition":
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "conversion", "strategy")
+		if strategy != "Webhook" {
+			return fmt.Errorf("CRD does not use webhook conversion")
+		}
+		return unstructured.SetNestedField(obj.Object, caBundle, "spec", "conversion", "webhook", "clientConfig", "caBundle")
+	default:
+		return fmt.Errorf("unsupported kind %s", obj.GetKind())
+	}
+}
@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// certificateBundle holds the parsed contents of a TLS secret.
+type certificateBundle struct {
+	// Leaf is the first certificate in tls.crt.
+	Leaf *x509.Certificate
+	// Intermediates are the remaining certificates in tls.crt.
+	Intermediates []*x509.Certificate
+	// CAs are the certificates in ca.crt.
+	CAs []*x509.Certificate
+	// PrivateKey is the parsed tls.key.
+	PrivateKey crypto.PrivateKey
+}
+
+// parseCertificateBundle parses the certificate chain, CA and private key from a TLS secret.
+func parseCertificateBundle(secret *corev1.Secret) (*certificateBundle, error) {
+	chain, err := parseCertificatesPEM(secret.Data["tls.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tls.crt: %v", err)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificates found in tls.crt")
+	}
+
+	cas, err := parseCertificatesPEM(secret.Data["ca.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca.crt: %v", err)
+	}
+
+	key, err := parsePrivateKeyPEM(secret.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tls.key: %v", err)
+	}
+
+	return &certificateBundle{
+		Leaf:          chain[0],
+		Intermediates: chain[1:],
+		CAs:           cas,
+		PrivateKey:    key,
+	}, nil
+}
+
+// Chain returns the leaf followed by the intermediates.
+func (b *certificateBundle) Chain() []*x509.Certificate {
+	return append([]*x509.Certificate{b.Leaf}, b.Intermediates...)
+}
+
+// FullChain returns the chain followed by any CA certificates not already part of it.
+func (b *certificateBundle) FullChain() []*x509.Certificate {
+	chain := b.Chain()
+	for _, ca := range b.CAs {
+		if !containsCertificate(chain, ca) {
+			chain = append(chain, ca)
+		}
+	}
+	return chain
+}
+
+// CABundle returns the intermediates followed by any CA certificates not already part of them.
+func (b *certificateBundle) CABundle() []*x509.Certificate {
+	return b.FullChain()[1:]
+}
+
+// TrustAnchors returns the CA certificates, falling back to the last certificate
+// of the chain when the secret has no ca.crt.
+func (b *certificateBundle) TrustAnchors() []*x509.Certificate {
+	if len(b.CAs) > 0 {
+		return b.CAs
+	}
+	if len(b.Intermediates) > 0 {
+		return b.Intermediates[len(b.Intermediates)-1:]
+	}
+	return nil
+}
+
+// parseCertificatesPEM parses all CERTIFICATE blocks in data.
+func parseCertificatesPEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// parsePrivateKeyPEM parses a PKCS#1, SEC1 or PKCS#8 encoded private key.
+func parsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+}
+
+// encodeCertificatesPEM encodes certificates as concatenated PEM blocks.
+func encodeCertificatesPEM(certs []*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return out
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateFingerprint returns the hex encoded SHA-256 fingerprint of cert.
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
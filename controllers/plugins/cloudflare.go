@@ -0,0 +1,277 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// defaultCloudflareEndpoint is the base URL of the Cloudflare API.
+const defaultCloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+
+// CloudflarePlugin uploads certificates to a Cloudflare zone as custom
+// certificates served at the edge. The ID of the custom certificate is
+// recorded in the destination status and the certificate is replaced in place
+// on renewal.
+type CloudflarePlugin struct {
+	client.Client
+}
+
+// cloudflareCertificate is a custom certificate as returned by the API.
+type cloudflareCertificate struct {
+	ID           string   `json:"id"`
+	Hosts        []string `json:"hosts"`
+	BundleMethod string   `json:"bundle_method"`
+	ExpiresOn    string   `json:"expires_on"`
+}
+
+// cloudflareResponse is the envelope of all Cloudflare API responses.
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// cloudflareError is returned for failed API requests.
+type cloudflareError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *cloudflareError) Error() string {
+	return fmt.Sprintf("cloudflare API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Name returns the plugin name.
+func (p *CloudflarePlugin) Name() string {
+	return "Cloudflare"
+}
+
+// Sync uploads the certificate, replacing the custom certificate named by
+// certificateName if set.
+func (p *CloudflarePlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	_, err := p.SyncResource(ctx, secret, destConfig, "")
+	return err
+}
+
+// SyncResource uploads the certificate as a new custom certificate, or replaces
+// the custom certificate with the given ID when it differs. The ID defaults to
+// certificateName. It returns the ID of the custom certificate.
+func (p *CloudflarePlugin) SyncResource(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig, certificateID string) (string, error) {
+	logger := log.FromContext(ctx)
+	certificateID = defaultString(certificateID, destConfig.CertificateName)
+
+	zone := destConfig.Cloudflare
+	if zone == nil || zone.ZoneID == "" {
+		return "", fmt.Errorf("cloudflare zoneID is required for Cloudflare destination")
+	}
+	if destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil {
+		return "", fmt.Errorf("Cloudflare does not accept encrypted private keys")
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return "", err
+	}
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+	if err != nil {
+		return "", err
+	}
+	token, err := p.apiToken(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+
+	bundleMethod := defaultString(zone.BundleMethod, "ubiquitous")
+	payload := map[string]string{
+		"certificate":   string(encodeCertificatesPEM(bundle.Chain())),
+		"private_key":   string(keyBytes),
+		"bundle_method": bundleMethod,
+	}
+	certificatesPath := fmt.Sprintf("/zones/%s/custom_certificates", zone.ZoneID)
+
+	if certificateID != "" {
+		var existing cloudflareCertificate
+		err := cloudflareRequest(ctx, zone, token, http.MethodGet, certificatesPath+"/"+certificateID, nil, &existing)
+		switch {
+		case isCloudflareNotFound(err):
+			logger.Info("Cloudflare custom certificate not found, uploading a new one", "certificate", certificateID)
+		case err != nil:
+			return certificateID, fmt.Errorf("failed to get custom certificate: %v", err)
+		case cloudflareCertificateMatches(&existing, bundle, bundleMethod):
+			logger.V(1).Info("Cloudflare custom certificate is up to date", "certificate", certificateID)
+			return certificateID, nil
+		default:
+			logger.Info("Replacing Cloudflare custom certificate", "certificate", certificateID)
+			var updated cloudflareCertificate
+			if err := cloudflareRequest(ctx, zone, token, http.MethodPatch, certificatesPath+"/"+certificateID, payload, &updated); err != nil {
+				return certificateID, fmt.Errorf("failed to replace custom certificate: %v", err)
+			}
+			return updated.ID, nil
+		}
+	}
+
+	logger.Info("Uploading Cloudflare custom certificate", "zone", zone.ZoneID)
+	payload["type"] = defaultString(zone.CertificateType, "sni_custom")
+	var created cloudflareCertificate
+	if err := cloudflareRequest(ctx, zone, token, http.MethodPost, certificatesPath, payload, &created); err != nil {
+		return "", fmt.Errorf("failed to upload custom certificate: %v", err)
+	}
+	return created.ID, nil
+}
+
+// CheckExists checks if the custom certificate named by certificateName exists.
+func (p *CloudflarePlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the custom certificate with the given ID
+// exists. The ID defaults to certificateName.
+func (p *CloudflarePlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, certificateID string) (bool, error) {
+	zone := destConfig.Cloudflare
+	certificateID = defaultString(certificateID, destConfig.CertificateName)
+	if zone == nil || certificateID == "" {
+		return false, nil
+	}
+
+	token, err := p.apiToken(ctx, zone)
+	if err != nil {
+		return false, err
+	}
+
+	path := fmt.Sprintf("/zones/%s/custom_certificates/%s", zone.ZoneID, certificateID)
+	err = cloudflareRequest(ctx, zone, token, http.MethodGet, path, nil, nil)
+	if isCloudflareNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the custom certificate named by certificateName.
+func (p *CloudflarePlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource deletes the custom certificate with the given ID. The ID
+// defaults to certificateName.
+func (p *CloudflarePlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, certificateID string) error {
+	zone := destConfig.Cloudflare
+	certificateID = defaultString(certificateID, destConfig.CertificateName)
+	if zone == nil || certificateID == "" {
+		return nil
+	}
+
+	token, err := p.apiToken(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Deleting Cloudflare custom certificate", "certificate", certificateID)
+	path := fmt.Sprintf("/zones/%s/custom_certificates/%s", zone.ZoneID, certificateID)
+	if err := cloudflareRequest(ctx, zone, token, http.MethodDelete, path, nil, nil); err != nil && !isCloudflareNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// apiToken reads the API token from the referenced secret.
+func (p *CloudflarePlugin) apiToken(ctx context.Context, zone *certautov1.CloudflareZone) (string, error) {
+	token, err := readSecretKeyRef(ctx, p.Client, &zone.APITokenSecretRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cloudflare API token: %v", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// cloudflareRequest sends an API request and decodes the result into out.
+func cloudflareRequest(ctx context.Context, zone *certautov1.CloudflareZone, token, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := defaultCloudflareEndpoint
+	if zone.Endpoint != "" {
+		// The API token is sent to the endpoint
+		if bindingScope(ctx).Provider == "" {
+			return fmt.Errorf("cloudflare endpoint may only be set by DestinationProvider configs")
+		}
+		endpoint = strings.TrimSuffix(zone.Endpoint, "/")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil && resp.StatusCode < 300 {
+		return fmt.Errorf("failed to decode cloudflare response: %v", err)
+	}
+	if resp.StatusCode >= 300 || !envelope.Success {
+		message := http.StatusText(resp.StatusCode)
+		if len(envelope.Errors) > 0 {
+			message = envelope.Errors[0].Message
+		}
+		return &cloudflareError{StatusCode: resp.StatusCode, Message: message}
+	}
+	if out != nil && len(envelope.Result) > 0 {
+		if err := json.Unmarshal(envelope.Result, out); err != nil {
+			return fmt.Errorf("failed to decode cloudflare result: %v", err)
+		}
+	}
+	return nil
+}
+
+// isCloudflareNotFound reports whether err is a Cloudflare not found error.
+func isCloudflareNotFound(err error) bool {
+	var cfErr *cloudflareError
+	return errors.As(err, &cfErr) && cfErr.StatusCode == http.StatusNotFound
+}
+
+// cloudflareCertificateMatches reports whether the custom certificate already
+// serves the leaf certificate. The API does not return the certificate, so
+// the expiry and hosts are compared.
+func cloudflareCertificateMatches(existing *cloudflareCertificate, bundle *certificateBundle, bundleMethod string) bool {
+	expiresOn, err := time.Parse(time.RFC3339, existing.ExpiresOn)
+	if err != nil || !expiresOn.Equal(bundle.Leaf.NotAfter.Truncate(time.Second)) {
+		return false
+	}
+	hosts := slices.Clone(bundle.Leaf.DNSNames)
+	slices.Sort(hosts)
+	existingHosts := slices.Clone(existing.Hosts)
+	slices.Sort(existingHosts)
+	return existing.BundleMethod == bundleMethod && slices.Equal(hosts, existingHosts)
+}
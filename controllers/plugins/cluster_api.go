@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// ClusterAPIClusterNameLabel is set by Cluster API on the secrets of a workload cluster.
+	ClusterAPIClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+
+	// clusterAPIKubeconfigKey is the key holding the kubeconfig in Cluster API kubeconfig secrets.
+	clusterAPIKubeconfigKey = "value"
+)
+
+// ClusterAPIClusterGroupKind is the group and kind of Cluster API Cluster objects.
+var ClusterAPIClusterGroupKind = schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"}
+
+// selectedCluster is a Cluster API workload cluster and how to connect to it.
+type selectedCluster struct {
+	name   string
+	remote *certautov1.RemoteCluster
+}
+
+// ClusterAPIKubeconfigSecretName returns the name of the kubeconfig secret
+// Cluster API creates for a workload cluster.
+func ClusterAPIKubeconfigSecretName(clusterName string) string {
+	return clusterName + "-kubeconfig"
+}
+
+// ClusterAPIClusterGVK returns the preferred version of the Cluster API Cluster
+// kind served by the API server.
+func ClusterAPIClusterGVK(mapper meta.RESTMapper) (schema.GroupVersionKind, error) {
+	mapping, err := mapper.RESTMapping(ClusterAPIClusterGroupKind)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return mapping.GroupVersionKind, nil
+}
+
+// selectedClusters lists the Cluster API clusters matching selector in the
+// selected namespace, which defaults to the namespace of the binding. Clusters
+// that are being deleted or whose kubeconfig secret does not exist yet are
+// skipped; they are picked up once Cluster API writes the kubeconfig.
+func (p *RemoteKubernetesPlugin) selectedClusters(ctx context.Context, selector *certautov1.ClusterSelector) ([]selectedCluster, error) {
+	logger := log.FromContext(ctx)
+
+	namespace, err := scopedNamespace(ctx, selector.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector: %v", err)
+	}
+	gvk, err := ClusterAPIClusterGVK(p.RESTMapper())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Cluster API clusters: %v", err)
+	}
+
+	labelSelector := labels.Everything()
+	if selector.LabelSelector != nil {
+		labelSelector, err = metav1.LabelSelectorAsSelector(selector.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster label selector: %v", err)
+		}
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := p.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: labelSelector}); err != nil {
+		return nil, fmt.Errorf("failed to list Cluster API clusters: %v", err)
+	}
+
+	var clusters []selectedCluster
+	for _, item := range list.Items {
+		name := item.GetNamespace() + "/" + item.GetName()
+		remote := clusterAPIRemoteCluster(item.GetNamespace(), item.GetName())
+		if item.GetDeletionTimestamp() != nil {
+			logger.Info("Skipping cluster that is being deleted", "cluster", name)
+			p.evictClient(kubeconfigCacheKey(ctx, remote.KubeconfigSecretRef))
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := p.Get(ctx, types.NamespacedName{Name: remote.KubeconfigSecretRef.Name, Namespace: item.GetNamespace()}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("Kubeconfig not yet available, skipping cluster", "cluster", name)
+				continue
+			}
+			return nil, fmt.Errorf("failed to get kubeconfig of cluster %s: %v", name, err)
+		}
+
+		clusters = append(clusters, selectedCluster{name: name, remote: remote})
+	}
+
+	return clusters, nil
+}
+
+// recordedCluster looks up a cluster recorded as namespace/name in the status
+// of a destination. The remote of the returned cluster is nil when the cluster
+// no longer exists or is being deleted, and its cached client is dropped.
+func (p *RemoteKubernetesPlugin) recordedCluster(ctx context.Context, name string) (selectedCluster, error) {
+	namespace, clusterName, ok := strings.Cut(name, "/")
+	if !ok {
+		return selectedCluster{}, fmt.Errorf("invalid cluster name %s", name)
+	}
+	gvk, err := ClusterAPIClusterGVK(p.RESTMapper())
+	if err != nil {
+		return selectedCluster{}, fmt.Errorf("failed to find Cluster API clusters: %v", err)
+	}
+
+	item := &unstructured.Unstructured{}
+	item.SetGroupVersionKind(gvk)
+	err = p.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: namespace}, item)
+	if err != nil && !errors.IsNotFound(err) {
+		return selectedCluster{}, fmt.Errorf("failed to get cluster %s: %v", name, err)
+	}
+
+	remote := clusterAPIRemoteCluster(namespace, clusterName)
+	if err != nil || item.GetDeletionTimestamp() != nil {
+		p.evictClient(kubeconfigCacheKey(ctx, remote.KubeconfigSecretRef))
+		return selectedCluster{name: name}, nil
+	}
+	return selectedCluster{name: name, remote: remote}, nil
+}
+
+// clusterAPIRemoteCluster returns how to connect to a Cluster API workload cluster.
+func clusterAPIRemoteCluster(namespace, name string) *certautov1.RemoteCluster {
+	return &certautov1.RemoteCluster{
+		KubeconfigSecretRef: &certautov1.SecretKeyRef{
+			Name:      ClusterAPIKubeconfigSecretName(name),
+			Namespace: namespace,
+			Key:       clusterAPIKubeconfigKey,
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// ConfigMapPlugin distributes the CA bundle of a certificate to ConfigMaps in
+// one or more namespaces. This is useful for clients that need to trust the
+// issuing CA of internal services.
+type ConfigMapPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *ConfigMapPlugin) Name() string {
+	return "ConfigMap"
+}
+
+// Sync writes the CA bundle to the ConfigMap in every target namespace.
+func (p *ConfigMapPlugin) Sync(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	namespaces := configMapNamespaces(destConfig)
+	if len(namespaces) == 0 {
+		return fmt.Errorf("targetNamespace or targetNamespaces is required for ConfigMap destination")
+	}
+	if destConfig.ConfigMapName == "" {
+		return fmt.Errorf("configMapName is required for ConfigMap destination")
+	}
+
+	bundle, err := parseCertificateBundle(sourceSecret)
+	if err != nil {
+		return err
+	}
+	caBundle := bundle.CABundle()
+	if len(caBundle) == 0 {
+		return fmt.Errorf("source secret has no CA certificates")
+	}
+
+	bundleKey := destConfig.BundleKey
+	if bundleKey == "" {
+		bundleKey = "ca.crt"
+	}
+
+	labels, annotations := reflectedSecretMetadata(sourceSecret, destConfig.SecretTemplate)
+
+	for _, namespace := range namespaces {
+		// Check if target namespace exists
+		ns := &corev1.Namespace{}
+		if err := p.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("target namespace %s does not exist", namespace)
+			}
+			return fmt.Errorf("failed to check target namespace: %v", err)
+		}
+
+		// Read the existing ConfigMap so salted truststores can be reused
+		existing := &corev1.ConfigMap{}
+		if err := p.Get(ctx, types.NamespacedName{Name: destConfig.ConfigMapName, Namespace: namespace}, existing); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to check existing configmap: %v", err)
+		}
+
+		data := map[string][]byte{
+			bundleKey: encodeCertificatesPEM(caBundle),
+		}
+		if err := renderOutputFormats(ctx, p.Client, sourceSecret, destConfig.OutputFormats, nil, data, configMapData(existing)); err != nil {
+			return err
+		}
+
+		if err := syncConfigMap(ctx, p.Client, destConfig.ConfigMapName, namespace, labels, annotations, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckExists checks if the ConfigMap exists in every target namespace.
+func (p *ConfigMapPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	namespaces := configMapNamespaces(destConfig)
+	if len(namespaces) == 0 || destConfig.ConfigMapName == "" {
+		return false, nil
+	}
+
+	for _, namespace := range namespaces {
+		cm := &corev1.ConfigMap{}
+		if err := p.Get(ctx, types.NamespacedName{Name: destConfig.ConfigMapName, Namespace: namespace}, cm); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Delete removes the ConfigMap from every target namespace.
+func (p *ConfigMapPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	if destConfig.ConfigMapName == "" {
+		return nil
+	}
+
+	for _, namespace := range configMapNamespaces(destConfig) {
+		cm := &corev1.ConfigMap{}
+		if err := p.Get(ctx, types.NamespacedName{Name: destConfig.ConfigMapName, Namespace: namespace}, cm); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get configmap: %v", err)
+		}
+		if cm.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+			logger.Info("ConfigMap is not managed by certauto, leaving it in place",
+				"targetNamespace", namespace,
+				"targetConfigMap", destConfig.ConfigMapName)
+			continue
+		}
+
+		logger.Info("Deleting CA bundle configmap",
+			"targetNamespace", namespace,
+			"targetConfigMap", destConfig.ConfigMapName)
+
+		if err := p.Client.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete configmap: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// configMapNamespaces returns the de-duplicated target namespaces of a ConfigMap destination.
+func configMapNamespaces(destConfig certautov1.DestinationConfig) []string {
+	var namespaces []string
+	if destConfig.TargetNamespace != "" {
+		namespaces = append(namespaces, destConfig.TargetNamespace)
+	}
+	for _, namespace := range destConfig.TargetNamespaces {
+		if namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// configMapData returns the data and binaryData of a ConfigMap as a single map.
+func configMapData(cm *corev1.ConfigMap) map[string][]byte {
+	data := map[string][]byte{}
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range cm.BinaryData {
+		data[key] = value
+	}
+	return data
+}
+
+// syncConfigMap creates or updates a ConfigMap holding data. Values that are
+// not valid UTF-8 are stored in binaryData. Existing ConfigMaps are only
+// updated when they are managed by certauto, and metadata added by other tools
+// is kept.
+func syncConfigMap(ctx context.Context, c client.Client, name, namespace string, labels, annotations map[string]string, data map[string][]byte) error {
+	logger := log.FromContext(ctx)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, c, cm, func() error {
+		if cm.ResourceVersion != "" && cm.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+			return fmt.Errorf("configmap %s/%s exists and is not managed by certauto", namespace, name)
+		}
+		mergeMetadata(cm, labels, annotations)
+		// Leave empty maps nil so unchanged ConfigMaps are not updated
+		cm.Data = nil
+		cm.BinaryData = nil
+		for key, value := range data {
+			if utf8.Valid(value) {
+				if cm.Data == nil {
+					cm.Data = map[string]string{}
+				}
+				cm.Data[key] = string(value)
+			} else {
+				if cm.BinaryData == nil {
+					cm.BinaryData = map[string][]byte{}
+				}
+				cm.BinaryData[key] = value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync configmap: %v", err)
+	}
+
+	logger.Info("Synced configmap",
+		"targetNamespace", namespace,
+		"targetConfigMap", name,
+		"result", result)
+
+	return nil
+}
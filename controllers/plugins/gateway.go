@@ -0,0 +1,344 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// GatewayPlugin serves certificates from a Gateway API listener. The secret is
+// reflected next to the Gateway or into another namespace, the listener's
+// tls.certificateRefs is pointed at it, and a ReferenceGrant is created when the
+// reference crosses namespaces.
+type GatewayPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *GatewayPlugin) Name() string {
+	return "GatewayAPI"
+}
+
+// Sync reflects the TLS secret and references it from the Gateway listener.
+func (p *GatewayPlugin) Sync(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	ref, secretRef, err := gatewaySecretRef(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	if !includePrivateKey(destConfig) || destConfig.TargetKind == "ConfigMap" ||
+		(destConfig.SecretType != "" && destConfig.SecretType != string(corev1.SecretTypeTLS)) {
+		return fmt.Errorf("GatewayAPI destination requires a kubernetes.io/tls secret with the private key")
+	}
+
+	gateway, err := p.getGateway(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if listenerIndex(gateway, ref.ListenerName) < 0 {
+		return fmt.Errorf("gateway %s/%s has no listener %s", ref.Namespace, ref.Name, ref.ListenerName)
+	}
+
+	// Reference the source secret directly when it already is the target
+	if secretRef.Namespace != sourceSecret.Namespace || secretRef.Name != sourceSecret.Name {
+		reflected := destConfig
+		reflected.TargetNamespace = secretRef.Namespace
+		reflector := &KubernetesReflectorPlugin{Client: p.Client}
+		if err := reflector.Sync(ctx, sourceSecret, reflected); err != nil {
+			return err
+		}
+	}
+
+	// Grant the reference before adding it so the listener resolves immediately
+	if secretRef.Namespace != ref.Namespace {
+		if err := p.ensureReferenceGrant(ctx, ref, secretRef); err != nil {
+			return err
+		}
+	}
+
+	return p.updateListener(ctx, gateway, ref, secretRef, true)
+}
+
+// CheckExists checks if the listener references the secret and the secret exists.
+func (p *GatewayPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	ref, secretRef, err := gatewaySecretRef(ctx, destConfig)
+	if err != nil {
+		return false, nil
+	}
+
+	gateway, err := p.getGateway(ctx, ref)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	i := listenerIndex(gateway, ref.ListenerName)
+	if i < 0 || certificateRefIndex(gateway.Spec.Listeners[i].TLS, gateway.Namespace, secretRef) < 0 {
+		return false, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := p.Get(ctx, secretRef, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the certificate reference from the listener, the
+// ReferenceGrant and the reflected secret. Secrets not created by certauto are
+// left in place.
+func (p *GatewayPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	ref, secretRef, err := gatewaySecretRef(ctx, destConfig)
+	if err != nil {
+		return nil
+	}
+
+	gateway, err := p.getGateway(ctx, ref)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if err := p.updateListener(ctx, gateway, ref, secretRef, false); err != nil {
+			return err
+		}
+	}
+
+	if secretRef.Namespace != ref.Namespace {
+		if err := p.deleteReferenceGrant(ctx, ref, secretRef); err != nil {
+			return err
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := p.Get(ctx, secretRef, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get secret: %v", err)
+	}
+	if secret.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+		logger.Info("Secret is not managed by certauto, leaving it in place",
+			"targetNamespace", secretRef.Namespace,
+			"targetSecret", secretRef.Name)
+		return nil
+	}
+
+	logger.Info("Deleting reflected secret",
+		"targetNamespace", secretRef.Namespace,
+		"targetSecret", secretRef.Name)
+	if err := p.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete secret: %v", err)
+	}
+	return nil
+}
+
+// gatewaySecretRef validates the destination and returns the listener reference
+// and the location of the secret it should serve. Both must be in the namespace
+// of the binding unless the config comes from a DestinationProvider.
+func gatewaySecretRef(ctx context.Context, destConfig certautov1.DestinationConfig) (*certautov1.GatewayListenerRef, types.NamespacedName, error) {
+	ref := destConfig.Gateway
+	if ref == nil || ref.Name == "" || ref.Namespace == "" || ref.ListenerName == "" {
+		return nil, types.NamespacedName{}, fmt.Errorf("gateway name, namespace and listenerName are required for GatewayAPI destination")
+	}
+	if destConfig.TargetSecretName == "" {
+		return nil, types.NamespacedName{}, fmt.Errorf("targetSecretName is required for GatewayAPI destination")
+	}
+
+	if _, err := scopedNamespace(ctx, ref.Namespace); err != nil {
+		return nil, types.NamespacedName{}, fmt.Errorf("invalid gateway: %v", err)
+	}
+
+	namespace := destConfig.TargetNamespace
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	if _, err := scopedNamespace(ctx, namespace); err != nil {
+		return nil, types.NamespacedName{}, fmt.Errorf("invalid target namespace: %v", err)
+	}
+	return ref, types.NamespacedName{Name: destConfig.TargetSecretName, Namespace: namespace}, nil
+}
+
+// getGateway fetches the referenced Gateway.
+func (p *GatewayPlugin) getGateway(ctx context.Context, ref *certautov1.GatewayListenerRef) (*gatewayv1.Gateway, error) {
+	gateway := &gatewayv1.Gateway{}
+	if err := p.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, gateway); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get gateway %s/%s: %v", ref.Namespace, ref.Name, err)
+	}
+	return gateway, nil
+}
+
+// updateListener adds or removes the reference to secretRef on the listener
+// and patches the Gateway if anything changed.
+func (p *GatewayPlugin) updateListener(ctx context.Context, gateway *gatewayv1.Gateway, ref *certautov1.GatewayListenerRef, secretRef types.NamespacedName, present bool) error {
+	logger := log.FromContext(ctx)
+
+	i := listenerIndex(gateway, ref.ListenerName)
+	if i < 0 {
+		return nil
+	}
+
+	original := gateway.DeepCopy()
+	listener := &gateway.Spec.Listeners[i]
+	j := certificateRefIndex(listener.TLS, gateway.Namespace, secretRef)
+	switch {
+	case present && j < 0:
+		if listener.TLS == nil {
+			mode := gatewayv1.TLSModeTerminate
+			listener.TLS = &gatewayv1.ListenerTLSConfig{Mode: &mode}
+		}
+		certRef := gatewayv1.SecretObjectReference{Name: gatewayv1.ObjectName(secretRef.Name)}
+		if secretRef.Namespace != gateway.Namespace {
+			namespace := gatewayv1.Namespace(secretRef.Namespace)
+			certRef.Namespace = &namespace
+		}
+		listener.TLS.CertificateRefs = append(listener.TLS.CertificateRefs, certRef)
+	case !present && j >= 0:
+		listener.TLS.CertificateRefs = append(listener.TLS.CertificateRefs[:j], listener.TLS.CertificateRefs[j+1:]...)
+	}
+
+	if equality.Semantic.DeepEqual(original.Spec, gateway.Spec) {
+		logger.Info("Gateway listener unchanged, skipping update",
+			"gateway", ref.Namespace+"/"+ref.Name,
+			"listener", ref.ListenerName)
+		return nil
+	}
+
+	logger.Info("Updating gateway listener certificate references",
+		"gateway", ref.Namespace+"/"+ref.Name,
+		"listener", ref.ListenerName)
+	if err := p.Patch(ctx, gateway, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("failed to patch gateway %s/%s: %v", ref.Namespace, ref.Name, err)
+	}
+	return nil
+}
+
+// ensureReferenceGrant allows Gateways in the Gateway namespace to reference the secret.
+func (p *GatewayPlugin) ensureReferenceGrant(ctx context.Context, ref *certautov1.GatewayListenerRef, secretRef types.NamespacedName) error {
+	logger := log.FromContext(ctx)
+
+	grant := &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      referenceGrantName(ref, secretRef),
+			Namespace: secretRef.Namespace,
+		},
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, p.Client, grant, func() error {
+		if grant.ResourceVersion != "" && grant.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+			return fmt.Errorf("reference grant %s/%s exists and is not managed by certauto", grant.Namespace, grant.Name)
+		}
+		if grant.Labels == nil {
+			grant.Labels = map[string]string{}
+		}
+		grant.Labels["app.kubernetes.io/managed-by"] = "certauto"
+
+		secretName := gatewayv1.ObjectName(secretRef.Name)
+		grant.Spec = gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{
+				Group:     gatewayv1.GroupName,
+				Kind:      "Gateway",
+				Namespace: gatewayv1.Namespace(ref.Namespace),
+			}},
+			To: []gatewayv1beta1.ReferenceGrantTo{{
+				Group: "",
+				Kind:  "Secret",
+				Name:  &secretName,
+			}},
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync reference grant: %v", err)
+	}
+
+	logger.Info("Synced reference grant",
+		"namespace", grant.Namespace,
+		"name", grant.Name,
+		"result", result)
+	return nil
+}
+
+// deleteReferenceGrant deletes the ReferenceGrant created by ensureReferenceGrant.
+// Grants not created by certauto are left in place.
+func (p *GatewayPlugin) deleteReferenceGrant(ctx context.Context, ref *certautov1.GatewayListenerRef, secretRef types.NamespacedName) error {
+	logger := log.FromContext(ctx)
+
+	grant := &gatewayv1beta1.ReferenceGrant{}
+	if err := p.Get(ctx, types.NamespacedName{Name: referenceGrantName(ref, secretRef), Namespace: secretRef.Namespace}, grant); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get reference grant: %v", err)
+	}
+	if grant.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+		logger.Info("Reference grant is not managed by certauto, leaving it in place",
+			"namespace", grant.Namespace,
+			"name", grant.Name)
+		return nil
+	}
+
+	logger.Info("Deleting reference grant", "namespace", grant.Namespace, "name", grant.Name)
+	if err := p.Client.Delete(ctx, grant); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete reference grant: %v", err)
+	}
+	return nil
+}
+
+// referenceGrantName returns the name of the ReferenceGrant that allows
+// Gateways in the Gateway namespace to reference the secret.
+func referenceGrantName(ref *certautov1.GatewayListenerRef, secretRef types.NamespacedName) string {
+	return fmt.Sprintf("certauto-%s-%s", secretRef.Name, ref.Namespace)
+}
+
+// listenerIndex returns the index of the named listener, or -1.
+func listenerIndex(gateway *gatewayv1.Gateway, name string) int {
+	for i, listener := range gateway.Spec.Listeners {
+		if string(listener.Name) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// certificateRefIndex returns the index of the reference to secretRef in tls, or -1.
+func certificateRefIndex(tls *gatewayv1.ListenerTLSConfig, gatewayNamespace string, secretRef types.NamespacedName) int {
+	if tls == nil {
+		return -1
+	}
+	for i, certRef := range tls.CertificateRefs {
+		if certRef.Group != nil && *certRef.Group != "" {
+			continue
+		}
+		if certRef.Kind != nil && *certRef.Kind != "Secret" {
+			continue
+		}
+		namespace := gatewayNamespace
+		if certRef.Namespace != nil {
+			namespace = string(*certRef.Namespace)
+		}
+		if string(certRef.Name) == secretRef.Name && namespace == secretRef.Namespace {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// gcpClientOptions returns the client options for a Google Cloud API. Requests
+// are authenticated with the referenced service account key, or else with the
+// application default credentials such as GKE workload identity. Plain http
+// endpoints, such as local emulators, are used without authentication. Only
+// DestinationProvider configs may override the endpoint.
+func gcpClientOptions(ctx context.Context, c client.Reader, gcp *certautov1.GCPProject) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+
+	if gcp.Endpoint != "" {
+		// The credentials are sent to the endpoint
+		if bindingScope(ctx).Provider == "" {
+			return nil, fmt.Errorf("gcp endpoint may only be set by DestinationProvider configs")
+		}
+		endpoint := gcp.Endpoint
+		if !strings.HasSuffix(endpoint, "/") {
+			endpoint += "/"
+		}
+		opts = append(opts, option.WithEndpoint(endpoint))
+		if strings.HasPrefix(endpoint, "http://") {
+			return append(opts, option.WithoutAuthentication()), nil
+		}
+	}
+
+	if gcp.CredentialsSecretRef != nil {
+		key, err := readSecretKeyRef(ctx, c, gcp.CredentialsSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account key: %v", err)
+		}
+		opts = append(opts, option.WithCredentialsJSON(key))
+	}
+
+	return opts, nil
+}
+
+// isGCPNotFound reports whether err is a Google API not found error.
+func isGCPNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
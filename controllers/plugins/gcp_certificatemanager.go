@@ -0,0 +1,291 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	certificatemanager "google.golang.org/api/certificatemanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// certificateManagerPollInterval is how often long running Certificate Manager
+// operations are polled.
+var certificateManagerPollInterval = 2 * time.Second
+
+// GCPCertificateManagerPlugin uploads certificates to Google Cloud Certificate
+// Manager as self-managed certificates, for use by external HTTPS load
+// balancers, and optionally points certificate map entries at them.
+type GCPCertificateManagerPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *GCPCertificateManagerPlugin) Name() string {
+	return "GCPCertificateManager"
+}
+
+// ResourceName returns the full resource name of the certificate.
+func (p *GCPCertificateManagerPlugin) ResourceName(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	if destConfig.GCP == nil {
+		return ""
+	}
+	return certificateManagerName(destConfig, certificateManagerID(secret, destConfig))
+}
+
+// Sync creates the certificate or updates it when the certificate or labels
+// changed, then updates the configured certificate map entries.
+func (p *GCPCertificateManagerPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	if destConfig.GCP == nil || destConfig.GCP.Project == "" {
+		return fmt.Errorf("gcp project is required for GCPCertificateManager destination")
+	}
+	if len(destConfig.CertificateMapEntries) > 0 && certificateManagerLocation(destConfig) != "global" {
+		return fmt.Errorf("certificate map entries require a global certificate")
+	}
+	if destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil {
+		return fmt.Errorf("Certificate Manager does not accept encrypted private keys")
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+	if err != nil {
+		return err
+	}
+
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	certID := certificateManagerID(secret, destConfig)
+	name := certificateManagerName(destConfig, certID)
+	labels := map[string]string{"managed-by": "certauto"}
+	maps.Copy(labels, destConfig.Labels)
+	certificate := &certificatemanager.Certificate{
+		Description: fmt.Sprintf("TLS certificate synced by certauto from %s/%s", secret.Namespace, secret.Name),
+		Labels:      labels,
+		SelfManaged: &certificatemanager.SelfManagedCertificate{
+			PemCertificate: string(encodeCertificatesPEM(bundle.FullChain())),
+			PemPrivateKey:  string(keyBytes),
+		},
+	}
+
+	// 1. Create the certificate or update it when it changed
+	existing, err := service.Projects.Locations.Certificates.Get(name).Context(ctx).Do()
+	switch {
+	case isGCPNotFound(err):
+		logger.Info("Creating Certificate Manager certificate", "certificate", name)
+		op, err := service.Projects.Locations.Certificates.Create(certificateManagerParent(destConfig), certificate).
+			CertificateId(certID).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create certificate: %v", err)
+		}
+		if err := waitCertificateManagerOperation(ctx, service, op); err != nil {
+			return fmt.Errorf("failed to create certificate: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get certificate: %v", err)
+	default:
+		current, _ := parseCertificatesPEM([]byte(existing.PemCertificate))
+		if len(current) > 0 && current[0].Equal(bundle.Leaf) && maps.Equal(existing.Labels, labels) {
+			logger.V(1).Info("Certificate Manager certificate is up to date", "certificate", name)
+			break
+		}
+		logger.Info("Updating Certificate Manager certificate", "certificate", name)
+		op, err := service.Projects.Locations.Certificates.Patch(name, certificate).
+			UpdateMask("selfManaged,labels,description").Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update certificate: %v", err)
+		}
+		if err := waitCertificateManagerOperation(ctx, service, op); err != nil {
+			return fmt.Errorf("failed to update certificate: %v", err)
+		}
+	}
+
+	// 2. Point the certificate map entries at the certificate
+	for _, ref := range destConfig.CertificateMapEntries {
+		if err := syncCertificateMapEntry(ctx, service, destConfig, ref, name, labels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckExists checks if the certificate exists in Certificate Manager.
+func (p *GCPCertificateManagerPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the certificate exists in Certificate Manager.
+// The name defaults to the certificate named by certificateName.
+func (p *GCPCertificateManagerPlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, name string) (bool, error) {
+	if destConfig.GCP == nil {
+		return false, nil
+	}
+	if name == "" && destConfig.CertificateName != "" {
+		name = certificateManagerName(destConfig, destConfig.CertificateName)
+	}
+	if name == "" {
+		return false, nil
+	}
+
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = service.Projects.Locations.Certificates.Get(name).Context(ctx).Do()
+	if isGCPNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the certificate from Certificate Manager. Certificates still
+// referenced by a certificate map entry cannot be deleted.
+func (p *GCPCertificateManagerPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource deletes the certificate from Certificate Manager. The name
+// defaults to the certificate named by certificateName.
+func (p *GCPCertificateManagerPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, name string) error {
+	if destConfig.GCP == nil {
+		return nil
+	}
+	if name == "" && destConfig.CertificateName != "" {
+		name = certificateManagerName(destConfig, destConfig.CertificateName)
+	}
+	if name == "" {
+		return nil
+	}
+
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Deleting Certificate Manager certificate", "certificate", name)
+	op, err := service.Projects.Locations.Certificates.Delete(name).Context(ctx).Do()
+	if isGCPNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return waitCertificateManagerOperation(ctx, service, op)
+}
+
+// service returns a Certificate Manager client for the destination.
+func (p *GCPCertificateManagerPlugin) service(ctx context.Context, destConfig certautov1.DestinationConfig) (*certificatemanager.Service, error) {
+	opts, err := gcpClientOptions(ctx, p.Client, destConfig.GCP)
+	if err != nil {
+		return nil, err
+	}
+	service, err := certificatemanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Certificate Manager client: %v", err)
+	}
+	return service, nil
+}
+
+// syncCertificateMapEntry points a certificate map entry at the certificate,
+// creating the entry if it does not exist.
+func syncCertificateMapEntry(ctx context.Context, service *certificatemanager.Service, destConfig certautov1.DestinationConfig, ref certautov1.CertificateMapEntryRef, certificate string, labels map[string]string) error {
+	logger := log.FromContext(ctx)
+
+	mapName := fmt.Sprintf("%s/certificateMaps/%s", certificateManagerParent(destConfig), ref.Map)
+	entryName := fmt.Sprintf("%s/certificateMapEntries/%s", mapName, ref.Name)
+
+	existing, err := service.Projects.Locations.CertificateMaps.CertificateMapEntries.Get(entryName).Context(ctx).Do()
+	var op *certificatemanager.Operation
+	switch {
+	case isGCPNotFound(err):
+		logger.Info("Creating certificate map entry", "entry", entryName)
+		entry := &certificatemanager.CertificateMapEntry{
+			Certificates: []string{certificate},
+			Hostname:     ref.Hostname,
+			Labels:       labels,
+		}
+		if ref.Hostname == "" {
+			entry.Matcher = "PRIMARY"
+		}
+		op, err = service.Projects.Locations.CertificateMaps.CertificateMapEntries.Create(mapName, entry).
+			CertificateMapEntryId(ref.Name).Context(ctx).Do()
+	case err != nil:
+		return fmt.Errorf("failed to get certificate map entry %s: %v", entryName, err)
+	case slices.Equal(existing.Certificates, []string{certificate}):
+		return nil
+	default:
+		logger.Info("Updating certificate map entry", "entry", entryName)
+		op, err = service.Projects.Locations.CertificateMaps.CertificateMapEntries.Patch(entryName, &certificatemanager.CertificateMapEntry{
+			Certificates: []string{certificate},
+		}).UpdateMask("certificates").Context(ctx).Do()
+	}
+	if err == nil {
+		err = waitCertificateManagerOperation(ctx, service, op)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update certificate map entry %s: %v", entryName, err)
+	}
+	return nil
+}
+
+// waitCertificateManagerOperation polls a long running operation until it is done.
+func waitCertificateManagerOperation(ctx context.Context, service *certificatemanager.Service, op *certificatemanager.Operation) error {
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(certificateManagerPollInterval):
+		}
+		var err error
+		if op, err = service.Projects.Locations.Operations.Get(op.Name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to get operation: %v", err)
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("operation failed: %s", op.Error.Message)
+	}
+	return nil
+}
+
+// certificateManagerID returns the certificate ID, defaulting to a name derived
+// from the source secret. IDs only allow lowercase letters, digits and hyphens.
+func certificateManagerID(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	if destConfig.CertificateName != "" {
+		return destConfig.CertificateName
+	}
+	return strings.ToLower(strings.ReplaceAll(generateCertName(secret), ".", "-"))
+}
+
+// certificateManagerParent returns the location resource name.
+func certificateManagerParent(destConfig certautov1.DestinationConfig) string {
+	return fmt.Sprintf("projects/%s/locations/%s", destConfig.GCP.Project, certificateManagerLocation(destConfig))
+}
+
+// certificateManagerName returns the full resource name of a certificate.
+func certificateManagerName(destConfig certautov1.DestinationConfig, certID string) string {
+	return fmt.Sprintf("%s/certificates/%s", certificateManagerParent(destConfig), certID)
+}
+
+// certificateManagerLocation returns the configured location, defaulting to global.
+func certificateManagerLocation(destConfig certautov1.DestinationConfig) string {
+	return defaultString(destConfig.Location, "global")
+}
@@ -0,0 +1,247 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"maps"
+
+	secretmanager "google.golang.org/api/secretmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// GCPSecretManagerPlugin stores certificates in Google Cloud Secret Manager.
+// Each certificate is added as a new secret version holding the PEM encoded
+// certificate chain followed by the private key.
+type GCPSecretManagerPlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *GCPSecretManagerPlugin) Name() string {
+	return "GCPSecretManager"
+}
+
+// ResourceName returns the resource name of the secret, whose ID defaults to
+// <namespace>-<name> of the source secret.
+func (p *GCPSecretManagerPlugin) ResourceName(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	if destConfig.GCP == nil {
+		return ""
+	}
+	return secretManagerName(destConfig.GCP.Project, defaultString(destConfig.CertificateName, generateCertName(secret)))
+}
+
+// Sync adds a secret version when the PEM bundle changed, keeps the labels up
+// to date and disables or destroys older versions if configured.
+func (p *GCPSecretManagerPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	if destConfig.GCP == nil || destConfig.GCP.Project == "" {
+		return fmt.Errorf("gcp project is required for GCPSecretManager destination")
+	}
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	secretID := defaultString(destConfig.CertificateName, generateCertName(secret))
+	name := secretManagerName(destConfig.GCP.Project, secretID)
+	labels := map[string]string{"managed-by": "certauto"}
+	maps.Copy(labels, destConfig.Labels)
+
+	// 1. Create the secret or update its labels
+	existing, err := service.Projects.Secrets.Get(name).Context(ctx).Do()
+	switch {
+	case isGCPNotFound(err):
+		logger.Info("Creating Secret Manager secret", "secret", name)
+		if _, err := service.Projects.Secrets.Create("projects/"+destConfig.GCP.Project, &secretmanager.Secret{
+			Labels:      labels,
+			Replication: &secretmanager.Replication{Automatic: &secretmanager.Automatic{}},
+		}).SecretId(secretID).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to create secret: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get secret: %v", err)
+	case !maps.Equal(existing.Labels, labels):
+		logger.Info("Updating Secret Manager secret labels", "secret", name)
+		if _, err := service.Projects.Secrets.Patch(name, &secretmanager.Secret{Labels: labels}).
+			UpdateMask("labels").Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to update secret labels: %v", err)
+		}
+	}
+
+	// 2. Add a version unless the latest one holds the same bundle
+	var current []byte
+	latest, err := service.Projects.Secrets.Versions.Access(name + "/versions/latest").Context(ctx).Do()
+	if err != nil && !isGCPNotFound(err) {
+		return fmt.Errorf("failed to access latest secret version: %v", err)
+	}
+	if err == nil && latest.Payload != nil {
+		if current, err = base64.StdEncoding.DecodeString(latest.Payload.Data); err != nil {
+			return fmt.Errorf("failed to decode latest secret version: %v", err)
+		}
+	}
+
+	bundle, err := secretManagerBundle(ctx, p.Client, secret, destConfig, current)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bundle, current) {
+		logger.Info("Adding Secret Manager secret version", "secret", name)
+		added, err := service.Projects.Secrets.AddVersion(name, &secretmanager.AddSecretVersionRequest{
+			Payload: &secretmanager.SecretPayload{
+				Data:       base64.StdEncoding.EncodeToString(bundle),
+				DataCrc32c: int64(crc32.Checksum(bundle, crc32.MakeTable(crc32.Castagnoli))),
+			},
+		}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to add secret version: %v", err)
+		}
+		latest = &secretmanager.AccessSecretVersionResponse{Name: added.Name}
+	}
+
+	// 3. Retire the versions older than the latest one
+	if destConfig.PreviousVersions == "" || destConfig.PreviousVersions == "Keep" {
+		return nil
+	}
+	return service.Projects.Secrets.Versions.List(name).Context(ctx).Pages(ctx, func(page *secretmanager.ListSecretVersionsResponse) error {
+		for _, version := range page.Versions {
+			if version.Name == latest.Name || version.State == "DESTROYED" {
+				continue
+			}
+			switch {
+			case destConfig.PreviousVersions == "Destroy":
+				logger.Info("Destroying previous secret version", "version", version.Name)
+				if _, err := service.Projects.Secrets.Versions.Destroy(version.Name, &secretmanager.DestroySecretVersionRequest{}).Context(ctx).Do(); err != nil {
+					return fmt.Errorf("failed to destroy secret version %s: %v", version.Name, err)
+				}
+			case version.State == "ENABLED":
+				logger.Info("Disabling previous secret version", "version", version.Name)
+				if _, err := service.Projects.Secrets.Versions.Disable(version.Name, &secretmanager.DisableSecretVersionRequest{}).Context(ctx).Do(); err != nil {
+					return fmt.Errorf("failed to disable secret version %s: %v", version.Name, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// CheckExists checks if the secret exists in Secret Manager.
+func (p *GCPSecretManagerPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the secret exists in Secret Manager. The name
+// defaults to the secret named by certificateName.
+func (p *GCPSecretManagerPlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, name string) (bool, error) {
+	if destConfig.GCP == nil {
+		return false, nil
+	}
+	if name = defaultSecretManagerName(destConfig, name); name == "" {
+		return false, nil
+	}
+
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = service.Projects.Secrets.Get(name).Context(ctx).Do()
+	if isGCPNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the secret and all of its versions from Secret Manager.
+func (p *GCPSecretManagerPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource deletes the secret and all of its versions from Secret
+// Manager. The name defaults to the secret named by certificateName.
+func (p *GCPSecretManagerPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, name string) error {
+	if destConfig.GCP == nil {
+		return nil
+	}
+	if name = defaultSecretManagerName(destConfig, name); name == "" {
+		return nil
+	}
+
+	service, err := p.service(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Deleting Secret Manager secret", "secret", name)
+	_, err = service.Projects.Secrets.Delete(name).Context(ctx).Do()
+	if err != nil && !isGCPNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// secretManagerName returns the resource name of a secret.
+func secretManagerName(project, secretID string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", project, secretID)
+}
+
+// defaultSecretManagerName returns name, or the resource name of the secret
+// named by certificateName.
+func defaultSecretManagerName(destConfig certautov1.DestinationConfig, name string) string {
+	if name == "" && destConfig.CertificateName != "" {
+		return secretManagerName(destConfig.GCP.Project, destConfig.CertificateName)
+	}
+	return name
+}
+
+// service returns a Secret Manager client for the destination.
+func (p *GCPSecretManagerPlugin) service(ctx context.Context, destConfig certautov1.DestinationConfig) (*secretmanager.Service, error) {
+	opts, err := gcpClientOptions(ctx, p.Client, destConfig.GCP)
+	if err != nil {
+		return nil, err
+	}
+	service, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Secret Manager client: %v", err)
+	}
+	return service, nil
+}
+
+// secretManagerBundle returns the PEM encoded full chain followed by the
+// private key. current is the latest stored bundle, used to keep an encrypted
+// private key stable.
+func secretManagerBundle(ctx context.Context, c client.Reader, secret *corev1.Secret, destConfig certautov1.DestinationConfig, current []byte) ([]byte, error) {
+	certs, err := parseCertificateBundle(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var existingKey []byte
+	for rest := current; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			existingKey = pem.EncodeToMemory(block)
+		}
+	}
+
+	keyBytes, err := encodePrivateKey(ctx, c, secret, destConfig.PrivateKey, existingKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(encodeCertificatesPEM(certs.FullChain()), keyBytes...), nil
+}
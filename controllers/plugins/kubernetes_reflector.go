@@ -3,6 +3,7 @@ package plugins
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -19,6 +20,10 @@ import (
 // and you need to sync them to application namespaces.
 type KubernetesReflectorPlugin struct {
 	client.Client
+
+	// ReferenceReader reads secrets referenced by the destination config, such
+	// as keystore passwords. Defaults to Client.
+	ReferenceReader client.Reader
 }
 
 // Name returns the plugin name.
@@ -51,6 +56,30 @@ func (p *KubernetesReflectorPlugin) Sync(ctx context.Context, sourceSecret *core
 		return fmt.Errorf("failed to check target namespace: %v", err)
 	}
 
+	// ConfigMap targets only carry public material
+	if destConfig.TargetKind == "ConfigMap" {
+		if destConfig.Versioned != nil {
+			return fmt.Errorf("versioned targets are only supported for secrets")
+		}
+		if includePrivateKey(destConfig) {
+			return fmt.Errorf("ConfigMap targets require includePrivateKey to be false")
+		}
+		_, data, err := p.targetSecretData(ctx, sourceSecret, destConfig, nil)
+		if err != nil {
+			return err
+		}
+		labels, annotations := reflectedSecretMetadata(sourceSecret, destConfig.SecretTemplate)
+		if err := syncConfigMap(ctx, p.Client, targetSecretName, targetNamespace, labels, annotations, data); err != nil {
+			return err
+		}
+		// Remove the private key written when the target was a secret
+		return p.deleteManagedSecret(ctx, targetNamespace, targetSecretName)
+	}
+
+	if destConfig.Versioned != nil {
+		return p.syncVersioned(ctx, sourceSecret, destConfig, targetNamespace, targetSecretName)
+	}
+
 	// Check if target secret already exists
 	existingSecret := &corev1.Secret{}
 	secretKey := types.NamespacedName{
@@ -67,36 +96,46 @@ func (p *KubernetesReflectorPlugin) Sync(ctx context.Context, sourceSecret *core
 		}
 	}
 
-	// Create or update the target secret
+	// Build the target secret
+	var existingData map[string][]byte
+	if exists {
+		existingData = existingSecret.Data
+	}
+	secretType, data, err := p.targetSecretData(ctx, sourceSecret, destConfig, existingData)
+	if err != nil {
+		return err
+	}
+
+	labels, annotations := reflectedSecretMetadata(sourceSecret, destConfig.SecretTemplate)
 	targetSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      targetSecretName,
-			Namespace: targetNamespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/managed-by":           "certauto",
-				"certauto.sanorg.in/source-name":      sourceSecret.Name,
-				"certauto.sanorg.in/source-namespace": sourceSecret.Namespace,
-			},
-			Annotations: map[string]string{
-				"certauto.sanorg.in/reflected-from": fmt.Sprintf("%s/%s", sourceSecret.Namespace, sourceSecret.Name),
-			},
-		},
-		Type: corev1.SecretTypeTLS,
-		Data: map[string][]byte{
-			"tls.crt": sourceSecret.Data["tls.crt"],
-			"tls.key": sourceSecret.Data["tls.key"],
+			Name:        targetSecretName,
+			Namespace:   targetNamespace,
+			Labels:      labels,
+			Annotations: annotations,
 		},
+		Type: secretType,
+		Data: data,
 	}
 
-	// Copy ca.crt if present
-	if caCrt, ok := sourceSecret.Data["ca.crt"]; ok {
-		targetSecret.Data["ca.crt"] = caCrt
+	// The secret type is immutable, so a type change requires recreating the secret
+	if exists && existingSecret.Type != targetSecret.Type {
+		logger.Info("Secret type changed, recreating reflected secret",
+			"targetNamespace", targetNamespace,
+			"targetSecret", targetSecretName,
+			"type", targetSecret.Type)
+
+		if err := p.Client.Delete(ctx, existingSecret); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret: %v", err)
+		}
+		exists = false
 	}
 
 	if exists {
-		// Check if data has changed
-		if secretDataEqual(existingSecret.Data, targetSecret.Data) {
-			logger.Info("Secret data unchanged, skipping update",
+		// Check if data or metadata has changed, keeping metadata added by other tools
+		metadataChanged := mergeMetadata(existingSecret, labels, annotations)
+		if secretDataEqual(existingSecret.Data, targetSecret.Data) && !metadataChanged {
+			logger.Info("Secret unchanged, skipping update",
 				"targetNamespace", targetNamespace,
 				"targetSecret", targetSecretName)
 			return nil
@@ -104,8 +143,6 @@ func (p *KubernetesReflectorPlugin) Sync(ctx context.Context, sourceSecret *core
 
 		// Update existing secret
 		existingSecret.Data = targetSecret.Data
-		existingSecret.Labels = targetSecret.Labels
-		existingSecret.Annotations = targetSecret.Annotations
 
 		logger.Info("Updating reflected secret",
 			"targetNamespace", targetNamespace,
@@ -128,6 +165,62 @@ func (p *KubernetesReflectorPlugin) Sync(ctx context.Context, sourceSecret *core
 	return nil
 }
 
+// targetSecretData builds the type and data of the target secret.
+func (p *KubernetesReflectorPlugin) targetSecretData(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig, existingData map[string][]byte) (corev1.SecretType, map[string][]byte, error) {
+	includeKey := includePrivateKey(destConfig)
+
+	secretType := corev1.SecretTypeTLS
+	if !includeKey {
+		secretType = corev1.SecretTypeOpaque
+	}
+	if destConfig.SecretType != "" {
+		secretType = corev1.SecretType(destConfig.SecretType)
+	}
+	if secretType != corev1.SecretTypeTLS && secretType != corev1.SecretTypeOpaque {
+		return "", nil, fmt.Errorf("unsupported secret type %s", secretType)
+	}
+	if secretType == corev1.SecretTypeTLS && !includeKey {
+		return "", nil, fmt.Errorf("secrets of type %s require the private key, use Opaque with includePrivateKey false", secretType)
+	}
+	encrypted := destConfig.PrivateKey != nil && destConfig.PrivateKey.PassphraseSecretRef != nil
+	// Consumers of kubernetes.io/tls secrets, such as ingress controllers, expect an unencrypted key
+	if secretType == corev1.SecretTypeTLS && encrypted {
+		return "", nil, fmt.Errorf("secrets of type %s require an unencrypted private key, use Opaque to distribute an encrypted key", secretType)
+	}
+
+	var keyPEM []byte
+	if includeKey {
+		var err error
+		keyPEM, err = encodePrivateKey(ctx, p.referenceReader(), sourceSecret, destConfig.PrivateKey, existingData["tls.key"])
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	data := map[string][]byte{}
+	if secretType == corev1.SecretTypeTLS || len(destConfig.DataTemplates) == 0 {
+		data["tls.crt"] = sourceSecret.Data["tls.crt"]
+		if includeKey {
+			data["tls.key"] = keyPEM
+		}
+
+		// Copy ca.crt if present
+		if caCrt, ok := sourceSecret.Data["ca.crt"]; ok {
+			data["ca.crt"] = caCrt
+		}
+	}
+
+	// Add any additional output formats and templated keys
+	if err := renderOutputFormats(ctx, p.referenceReader(), sourceSecret, destConfig.OutputFormats, keyPEM, data, existingData); err != nil {
+		return "", nil, err
+	}
+	if err := renderDataTemplates(sourceSecret, destConfig.DataTemplates, keyPEM, encrypted, data); err != nil {
+		return "", nil, err
+	}
+
+	return secretType, data, nil
+}
+
 // CheckExists checks if the secret exists in the target namespace.
 func (p *KubernetesReflectorPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
 	targetNamespace := destConfig.TargetNamespace
@@ -137,11 +230,10 @@ func (p *KubernetesReflectorPlugin) CheckExists(ctx context.Context, destConfig
 		return false, nil
 	}
 
-	secret := &corev1.Secret{}
 	err := p.Get(ctx, types.NamespacedName{
 		Name:      targetSecretName,
 		Namespace: targetNamespace,
-	}, secret)
+	}, targetObject(destConfig))
 
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -153,7 +245,8 @@ func (p *KubernetesReflectorPlugin) CheckExists(ctx context.Context, destConfig
 	return true, nil
 }
 
-// DeleteSecret removes the reflected secret from the target namespace.
+// Delete removes the reflected secret from the target namespace. Secrets not
+// managed by certauto are left in place.
 func (p *KubernetesReflectorPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
 	logger := log.FromContext(ctx)
 
@@ -164,18 +257,31 @@ func (p *KubernetesReflectorPlugin) Delete(ctx context.Context, destConfig certa
 		return nil
 	}
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      targetSecretName,
-			Namespace: targetNamespace,
-		},
+	if destConfig.Versioned != nil {
+		if err := p.deleteVersioned(ctx, targetNamespace, targetSecretName); err != nil {
+			return err
+		}
+	}
+
+	obj := targetObject(destConfig)
+	if err := p.Get(ctx, types.NamespacedName{Name: targetSecretName, Namespace: targetNamespace}, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get secret: %v", err)
+	}
+	if obj.GetLabels()["app.kubernetes.io/managed-by"] != "certauto" {
+		logger.Info("Secret is not managed by certauto, leaving it in place",
+			"targetNamespace", targetNamespace,
+			"targetSecret", targetSecretName)
+		return nil
 	}
 
 	logger.Info("Deleting reflected secret",
 		"targetNamespace", targetNamespace,
 		"targetSecret", targetSecretName)
 
-	if err := p.Client.Delete(ctx, secret); err != nil {
+	if err := p.Client.Delete(ctx, obj); err != nil {
 		if errors.IsNotFound(err) {
 			return nil
 		}
@@ -185,6 +291,96 @@ func (p *KubernetesReflectorPlugin) Delete(ctx context.Context, destConfig certa
 	return nil
 }
 
+// deleteManagedSecret deletes the secret and any generations written by
+// certauto under name. Secrets that certauto does not manage are kept.
+func (p *KubernetesReflectorPlugin) deleteManagedSecret(ctx context.Context, namespace, name string) error {
+	logger := log.FromContext(ctx)
+
+	if err := p.deleteVersioned(ctx, namespace, name); err != nil {
+		return err
+	}
+
+	existing := &corev1.Secret{}
+	if err := p.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, existing); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to check existing secret: %v", err)
+	}
+	if existing.Labels["app.kubernetes.io/managed-by"] != "certauto" {
+		return nil
+	}
+
+	logger.Info("Deleting reflected secret replaced by a configmap",
+		"targetNamespace", namespace,
+		"targetSecret", name)
+	if err := p.Client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete secret: %v", err)
+	}
+	return nil
+}
+
+// referenceReader returns the reader used for secrets referenced by the destination config.
+func (p *KubernetesReflectorPlugin) referenceReader() client.Reader {
+	if p.ReferenceReader != nil {
+		return p.ReferenceReader
+	}
+	return p.Client
+}
+
+// includePrivateKey reports whether the private key should be distributed.
+func includePrivateKey(destConfig certautov1.DestinationConfig) bool {
+	return destConfig.IncludePrivateKey == nil || *destConfig.IncludePrivateKey
+}
+
+// targetObject returns an empty object of the configured target kind.
+func targetObject(destConfig certautov1.DestinationConfig) client.Object {
+	if destConfig.TargetKind == "ConfigMap" {
+		return &corev1.ConfigMap{}
+	}
+	return &corev1.Secret{}
+}
+
+// reflectedSecretMetadata builds the labels and annotations for a reflected secret.
+// Template values are applied first so that certauto's tracking metadata always wins.
+func reflectedSecretMetadata(sourceSecret *corev1.Secret, tmpl *certautov1.SecretTemplate) (map[string]string, map[string]string) {
+	labels := map[string]string{}
+	annotations := map[string]string{}
+	if tmpl != nil {
+		maps.Copy(labels, tmpl.Labels)
+		maps.Copy(annotations, tmpl.Annotations)
+	}
+
+	labels["app.kubernetes.io/managed-by"] = "certauto"
+	labels["certauto.sanorg.in/source-name"] = sourceSecret.Name
+	labels["certauto.sanorg.in/source-namespace"] = sourceSecret.Namespace
+	annotations["certauto.sanorg.in/reflected-from"] = fmt.Sprintf("%s/%s", sourceSecret.Namespace, sourceSecret.Name)
+
+	return labels, annotations
+}
+
+// mergeMetadata sets the labels and annotations managed by certauto on obj and
+// keeps those added by other tools. It reports whether obj changed.
+func mergeMetadata(obj metav1.Object, labels, annotations map[string]string) bool {
+	changed := false
+	merge := func(existing, managed map[string]string) map[string]string {
+		for key, value := range managed {
+			if current, ok := existing[key]; ok && current == value {
+				continue
+			}
+			if existing == nil {
+				existing = map[string]string{}
+			}
+			existing[key] = value
+			changed = true
+		}
+		return existing
+	}
+	obj.SetLabels(merge(obj.GetLabels(), labels))
+	obj.SetAnnotations(merge(obj.GetAnnotations(), annotations))
+	return changed
+}
+
 // secretDataEqual compares two secret data maps for equality.
 func secretDataEqual(a, b map[string][]byte) bool {
 	if len(a) != len(b) {
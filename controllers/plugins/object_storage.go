@@ -0,0 +1,296 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// objectStorageKeys are the objects written for every certificate, named like
+// the keys of a kubernetes.io/tls secret.
+var objectStorageKeys = []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, "ca.crt"}
+
+// ObjectStoragePlugin writes certificates as objects to an S3-compatible
+// bucket, such as AWS S3 or MinIO, for pipelines and appliances that pull
+// certificates from a bucket. Objects are only written when their content
+// changes.
+type ObjectStoragePlugin struct {
+	client.Client
+}
+
+// Name returns the plugin name.
+func (p *ObjectStoragePlugin) Name() string {
+	return "ObjectStorage"
+}
+
+// Sync writes the certificate objects and any output formats, and removes
+// objects that are no longer distributed.
+func (p *ObjectStoragePlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	storage := destConfig.ObjectStorage
+	if storage == nil || storage.Bucket == "" {
+		return fmt.Errorf("objectStorage bucket is required for ObjectStorage destination")
+	}
+	s3Client, err := p.s3Client(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Clone(objectStorageKeys)
+	for _, format := range destConfig.OutputFormats {
+		keys = append(keys, format.Key)
+	}
+	existing := map[string][]byte{}
+	for _, key := range keys {
+		value, err := getObject(ctx, s3Client, storage, key)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			existing[key] = value
+		}
+	}
+
+	data := map[string][]byte{
+		corev1.TLSCertKey: encodeCertificatesPEM(bundle.Chain()),
+	}
+	if ca, ok := secret.Data["ca.crt"]; ok {
+		data["ca.crt"] = ca
+	}
+	var keyPEM []byte
+	if includePrivateKey(destConfig) {
+		keyPEM, err = encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, existing[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return err
+		}
+		data[corev1.TLSPrivateKeyKey] = keyPEM
+	}
+	if err := renderOutputFormats(ctx, p.Client, secret, destConfig.OutputFormats, keyPEM, data, existing); err != nil {
+		return err
+	}
+
+	tags := map[string]string{"ManagedBy": "certauto"}
+	maps.Copy(tags, destConfig.Tags)
+
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		objectKey := objectStorageKey(storage, key)
+		if bytes.Equal(existing[key], data[key]) {
+			if err := syncObjectTags(ctx, s3Client, storage, objectKey, tags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		logger.Info("Writing certificate object", "bucket", storage.Bucket, "key", objectKey)
+		input := &s3.PutObjectInput{
+			Bucket:  aws.String(storage.Bucket),
+			Key:     aws.String(objectKey),
+			Body:    bytes.NewReader(data[key]),
+			Tagging: aws.String(encodeObjectTags(tags)),
+		}
+		if destConfig.KMSKeyID != "" {
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			input.SSEKMSKeyId = aws.String(destConfig.KMSKeyID)
+		}
+		if _, err := s3Client.PutObject(ctx, input); err != nil {
+			return fmt.Errorf("failed to put object %s: %v", objectKey, err)
+		}
+	}
+
+	// Remove the private key and CA when they are no longer distributed
+	for _, key := range objectStorageKeys {
+		if _, ok := data[key]; ok || existing[key] == nil {
+			continue
+		}
+		logger.Info("Deleting stale certificate object", "bucket", storage.Bucket, "key", objectStorageKey(storage, key))
+		if _, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(storage.Bucket),
+			Key:    aws.String(objectStorageKey(storage, key)),
+		}); err != nil {
+			return fmt.Errorf("failed to delete object %s: %v", objectStorageKey(storage, key), err)
+		}
+	}
+
+	return nil
+}
+
+// CheckExists checks if the certificate object exists in the bucket.
+func (p *ObjectStoragePlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	storage := destConfig.ObjectStorage
+	if storage == nil || storage.Bucket == "" {
+		return false, nil
+	}
+	s3Client, err := p.s3Client(ctx, destConfig)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(objectStorageKey(storage, corev1.TLSCertKey)),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes the certificate objects and output formats from the bucket.
+func (p *ObjectStoragePlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	storage := destConfig.ObjectStorage
+	if storage == nil || storage.Bucket == "" {
+		return nil
+	}
+	s3Client, err := p.s3Client(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Clone(objectStorageKeys)
+	for _, format := range destConfig.OutputFormats {
+		keys = append(keys, format.Key)
+	}
+	for _, key := range keys {
+		// Deleting a missing object succeeds
+		if _, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(storage.Bucket),
+			Key:    aws.String(objectStorageKey(storage, key)),
+		}); err != nil {
+			return fmt.Errorf("failed to delete object %s: %v", objectStorageKey(storage, key), err)
+		}
+	}
+	return nil
+}
+
+// s3Client returns an S3 client for the destination, using the referenced
+// static credentials and endpoint if set.
+func (p *ObjectStoragePlugin) s3Client(ctx context.Context, destConfig certautov1.DestinationConfig) (*s3.Client, error) {
+	storage := destConfig.ObjectStorage
+
+	cfg, err := loadAWSConfig(ctx, destConfig)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		// S3-compatible services such as MinIO accept any region
+		cfg.Region = "us-east-1"
+	}
+
+	if storage.AccessKeyIDSecretRef != nil || storage.SecretAccessKeySecretRef != nil {
+		if storage.AccessKeyIDSecretRef == nil || storage.SecretAccessKeySecretRef == nil {
+			return nil, fmt.Errorf("accessKeyIdSecretRef and secretAccessKeySecretRef must be set together")
+		}
+		accessKeyID, err := readSecretKeyRef(ctx, p.Client, storage.AccessKeyIDSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read access key ID: %v", err)
+		}
+		secretAccessKey, err := readSecretKeyRef(ctx, p.Client, storage.SecretAccessKeySecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret access key: %v", err)
+		}
+		cfg.Credentials = credentials.NewStaticCredentialsProvider(
+			strings.TrimSpace(string(accessKeyID)), strings.TrimSpace(string(secretAccessKey)), "")
+	}
+
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if storage.Endpoint != "" {
+			o.BaseEndpoint = aws.String(storage.Endpoint)
+		}
+		o.UsePathStyle = storage.UsePathStyle
+	}), nil
+}
+
+// getObject returns the content of an object, or nil if it does not exist.
+func getObject(ctx context.Context, s3Client *s3.Client, storage *certautov1.ObjectStorage, key string) ([]byte, error) {
+	objectKey := objectStorageKey(storage, key)
+	out, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(objectKey),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %v", objectKey, err)
+	}
+	defer out.Body.Close()
+
+	value, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %v", objectKey, err)
+	}
+	return value, nil
+}
+
+// syncObjectTags replaces the tags of an object when they differ.
+func syncObjectTags(ctx context.Context, s3Client *s3.Client, storage *certautov1.ObjectStorage, objectKey string, tags map[string]string) error {
+	out, err := s3Client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(storage.Bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get tags of object %s: %v", objectKey, err)
+	}
+	current := map[string]string{}
+	for _, tag := range out.TagSet {
+		current[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if maps.Equal(current, tags) {
+		return nil
+	}
+
+	var tagSet []types.Tag
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	if _, err := s3Client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(storage.Bucket),
+		Key:     aws.String(objectKey),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	}); err != nil {
+		return fmt.Errorf("failed to tag object %s: %v", objectKey, err)
+	}
+	return nil
+}
+
+// encodeObjectTags encodes tags for the x-amz-tagging header.
+func encodeObjectTags(tags map[string]string) string {
+	values := url.Values{}
+	for key, value := range tags {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// objectStorageKey returns the key of an object below the configured prefix.
+func objectStorageKey(storage *certautov1.ObjectStorage, name string) string {
+	return path.Join(strings.Trim(storage.Prefix, "/"), name)
+}
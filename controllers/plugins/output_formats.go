@@ -0,0 +1,244 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"software.sslmate.com/src/go-pkcs12"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// renderOutputFormats adds the configured output formats to data. keyPEM is
+// the private key as written to the destination, or nil when the private key
+// is not distributed.
+//
+// PKCS#12 and JKS encodings are salted, so a value from the existing target
+// secret is reused when it still opens with the configured password and holds
+// the same certificates. This keeps reconciles idempotent.
+func renderOutputFormats(ctx context.Context, c client.Reader, sourceSecret *corev1.Secret, formats []certautov1.OutputFormat, keyPEM []byte, data, existing map[string][]byte) error {
+	if len(formats) == 0 {
+		return nil
+	}
+
+	bundle, err := parseCertificateBundle(sourceSecret)
+	if err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		if format.Key == "" {
+			return fmt.Errorf("outputFormats entry with format %s has no key", format.Format)
+		}
+
+		if keyPEM == nil && formatIncludesPrivateKey(format.Format) {
+			return fmt.Errorf("output format %s of %s requires the private key", format.Format, format.Key)
+		}
+
+		var password []byte
+		if format.PasswordSecretRef != nil {
+			password, err = readSecretKeyRef(ctx, c, format.PasswordSecretRef)
+			if err != nil {
+				return fmt.Errorf("failed to read password for %s: %v", format.Key, err)
+			}
+		}
+
+		if keystoreMatches(format.Format, existing[format.Key], password, bundle) {
+			data[format.Key] = existing[format.Key]
+			continue
+		}
+
+		value, err := encodeOutputFormat(format.Format, bundle, keyPEM, password)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s as %s: %v", format.Key, format.Format, err)
+		}
+		data[format.Key] = value
+	}
+
+	return nil
+}
+
+// encodeOutputFormat encodes the certificate bundle in the given format.
+// keyPEM is the PEM encoded private key used for CombinedPEM output.
+func encodeOutputFormat(format certautov1.OutputFormatType, bundle *certificateBundle, keyPEM, password []byte) ([]byte, error) {
+	switch format {
+	case certautov1.OutputFormatPKCS12:
+		return pkcs12.Modern.Encode(bundle.PrivateKey, bundle.Leaf, bundle.CABundle(), string(password))
+	case certautov1.OutputFormatPKCS12Truststore:
+		cas := bundle.TrustAnchors()
+		if len(cas) == 0 {
+			return nil, fmt.Errorf("no CA certificates available for truststore")
+		}
+		return pkcs12.Modern.EncodeTrustStore(cas, string(password))
+	case certautov1.OutputFormatJKS:
+		return encodeJKSKeystore(bundle, password)
+	case certautov1.OutputFormatJKSTruststore:
+		return encodeJKSTruststore(bundle, password)
+	case certautov1.OutputFormatDER:
+		return bundle.Leaf.Raw, nil
+	case certautov1.OutputFormatCombinedPEM:
+		return append(append([]byte{}, keyPEM...), encodeCertificatesPEM(bundle.FullChain())...), nil
+	case certautov1.OutputFormatFullChain:
+		return encodeCertificatesPEM(bundle.FullChain()), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// formatIncludesPrivateKey reports whether the output format carries the private key.
+func formatIncludesPrivateKey(format certautov1.OutputFormatType) bool {
+	switch format {
+	case certautov1.OutputFormatPKCS12, certautov1.OutputFormatJKS, certautov1.OutputFormatCombinedPEM:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeJKSKeystore encodes the private key and full chain as a Java keystore.
+func encodeJKSKeystore(bundle *certificateBundle, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, fmt.Errorf("a password is required for JKS keystores")
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(bundle.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := keystore.PrivateKeyEntry{
+		CreationTime: bundle.Leaf.NotBefore,
+		PrivateKey:   keyDER,
+	}
+	for _, cert := range bundle.FullChain() {
+		entry.CertificateChain = append(entry.CertificateChain, keystore.Certificate{Type: "X509", Content: cert.Raw})
+	}
+
+	ks := keystore.New()
+	if err := ks.SetPrivateKeyEntry("certificate", entry, password); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, password); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJKSTruststore encodes the CA certificates as a Java truststore.
+func encodeJKSTruststore(bundle *certificateBundle, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, fmt.Errorf("a password is required for JKS truststores")
+	}
+
+	cas := bundle.TrustAnchors()
+	if len(cas) == 0 {
+		return nil, fmt.Errorf("no CA certificates available for truststore")
+	}
+
+	ks := keystore.New()
+	for i, ca := range cas {
+		if err := ks.SetTrustedCertificateEntry(truststoreAlias(i), keystore.TrustedCertificateEntry{
+			CreationTime: ca.NotBefore,
+			Certificate:  keystore.Certificate{Type: "X509", Content: ca.Raw},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, password); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truststoreAlias returns the alias of the i-th CA certificate in a JKS truststore.
+func truststoreAlias(i int) string {
+	if i == 0 {
+		return "ca"
+	}
+	return fmt.Sprintf("ca-%d", i)
+}
+
+// keystoreMatches reports whether a previously rendered salted keystore opens
+// with password and holds the certificates of bundle. Formats that are not
+// salted always return false.
+func keystoreMatches(format certautov1.OutputFormatType, data, password []byte, bundle *certificateBundle) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	switch format {
+	case certautov1.OutputFormatPKCS12:
+		_, leaf, cas, err := pkcs12.DecodeChain(data, string(password))
+		if err != nil {
+			return false
+		}
+		return certificatesEqual(append([]*x509.Certificate{leaf}, cas...), bundle.FullChain())
+	case certautov1.OutputFormatPKCS12Truststore:
+		cas, err := pkcs12.DecodeTrustStore(data, string(password))
+		if err != nil {
+			return false
+		}
+		return certificatesEqual(cas, bundle.TrustAnchors())
+	case certautov1.OutputFormatJKS:
+		ks := keystore.New()
+		if err := ks.Load(bytes.NewReader(data), password); err != nil {
+			return false
+		}
+		chain, err := ks.GetPrivateKeyEntryCertificateChain("certificate")
+		if err != nil {
+			return false
+		}
+		var contents [][]byte
+		for _, cert := range chain {
+			contents = append(contents, cert.Content)
+		}
+		return rawCertificatesEqual(contents, bundle.FullChain())
+	case certautov1.OutputFormatJKSTruststore:
+		ks := keystore.New()
+		if err := ks.Load(bytes.NewReader(data), password); err != nil {
+			return false
+		}
+		cas := bundle.TrustAnchors()
+		if len(ks.Aliases()) != len(cas) {
+			return false
+		}
+		for i, ca := range cas {
+			entry, err := ks.GetTrustedCertificateEntry(truststoreAlias(i))
+			if err != nil || !bytes.Equal(entry.Certificate.Content, ca.Raw) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func certificatesEqual(a, b []*x509.Certificate) bool {
+	var contents [][]byte
+	for _, cert := range a {
+		contents = append(contents, cert.Raw)
+	}
+	return rawCertificatesEqual(contents, b)
+}
+
+func rawCertificatesEqual(contents [][]byte, certs []*x509.Certificate) bool {
+	if len(contents) != len(certs) {
+		return false
+	}
+	for i, cert := range certs {
+		if !bytes.Equal(contents[i], cert.Raw) {
+			return false
+		}
+	}
+	return true
+}
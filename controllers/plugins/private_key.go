@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/youmark/pkcs8"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// encodePrivateKey re-encodes the private key of the source secret according
+// to opts and returns it as PEM. The source tls.key is returned unchanged when
+// no encoding or passphrase is configured.
+//
+// Encrypted keys are salted, so existingKey is returned instead when it
+// decrypts with the configured passphrase to the same key. This keeps
+// reconciles idempotent.
+func encodePrivateKey(ctx context.Context, c client.Reader, sourceSecret *corev1.Secret, opts *certautov1.PrivateKeyOptions, existingKey []byte) ([]byte, error) {
+	if opts == nil || (opts.Encoding == "" && opts.PassphraseSecretRef == nil) {
+		return sourceSecret.Data["tls.key"], nil
+	}
+
+	key, err := parsePrivateKeyPEM(sourceSecret.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tls.key: %v", err)
+	}
+
+	if opts.PassphraseSecretRef == nil {
+		return marshalPrivateKeyPEM(key, opts.Encoding)
+	}
+
+	if opts.Encoding != "" && opts.Encoding != certautov1.PrivateKeyEncodingPKCS8 {
+		return nil, fmt.Errorf("encrypted private keys require PKCS8 encoding, got %s", opts.Encoding)
+	}
+
+	passphrase, err := readSecretKeyRef(ctx, c, opts.PassphraseSecretRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key passphrase: %v", err)
+	}
+
+	if encryptedKeyMatches(existingKey, passphrase, key) {
+		return existingKey, nil
+	}
+
+	der, err := pkcs8.MarshalPrivateKey(key, passphrase, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+}
+
+// marshalPrivateKeyPEM encodes key as PEM using the given encoding.
+func marshalPrivateKeyPEM(key crypto.PrivateKey, encoding certautov1.PrivateKeyEncoding) ([]byte, error) {
+	switch encoding {
+	case certautov1.PrivateKeyEncodingPKCS1:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("PKCS1 encoding requires an RSA key, got %T", key)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), nil
+	case certautov1.PrivateKeyEncodingSEC1:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("SEC1 encoding requires an ECDSA key, got %T", key)
+		}
+		der, err := x509.MarshalECPrivateKey(ecKey)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	case certautov1.PrivateKeyEncodingPKCS8, "":
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key encoding %q", encoding)
+	}
+}
+
+// encryptedKeyMatches reports whether data is an encrypted PKCS#8 key that
+// decrypts with passphrase to key.
+func encryptedKeyMatches(data, passphrase []byte, key crypto.PrivateKey) bool {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		return false
+	}
+
+	decrypted, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+	if err != nil {
+		return false
+	}
+
+	equaler, ok := decrypted.(interface{ Equal(crypto.PrivateKey) bool })
+	return ok && equaler.Equal(key)
+}
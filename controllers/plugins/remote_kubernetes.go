@@ -0,0 +1,375 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// remoteClusterTimeout bounds every request made to a remote cluster.
+const remoteClusterTimeout = 30 * time.Second
+
+// RemoteKubernetesPlugin reflects TLS secrets into namespaces of remote
+// clusters. This is useful when certificates are issued centrally in a hub
+// cluster and needed in edge clusters that do not run cert-manager. Instead of
+// a single cluster, a destination can select Cluster API workload clusters by
+// label, in which case every matching cluster receives the secret.
+//
+// Clients are built from the referenced credentials and cached per cluster
+// until the credentials change.
+type RemoteKubernetesPlugin struct {
+	client.Client
+
+	mu      sync.Mutex
+	clients map[string]cachedRemoteClient
+}
+
+// cachedRemoteClient is a remote cluster client and the hash of the credentials it was built from.
+type cachedRemoteClient struct {
+	credentialsHash string
+	client          client.Client
+}
+
+// Name returns the plugin name.
+func (p *RemoteKubernetesPlugin) Name() string {
+	return "RemoteKubernetes"
+}
+
+// Sync copies the TLS secret to the target namespace of the remote cluster, or
+// of every selected Cluster API cluster.
+func (p *RemoteKubernetesPlugin) Sync(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	return p.forEachCluster(ctx, destConfig, func(reflector *KubernetesReflectorPlugin) error {
+		return reflector.Sync(ctx, sourceSecret, destConfig)
+	})
+}
+
+// CheckExists checks if the secret exists in the target namespace of every remote cluster.
+func (p *RemoteKubernetesPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	found := 0
+	allExist := true
+	err := p.forEachCluster(ctx, destConfig, func(reflector *KubernetesReflectorPlugin) error {
+		found++
+		exists, err := reflector.CheckExists(ctx, destConfig)
+		if err != nil {
+			return err
+		}
+		allExist = allExist && exists
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return found > 0 && allExist, nil
+}
+
+// Delete removes the reflected secret from the target namespace of every remote cluster.
+func (p *RemoteKubernetesPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.forEachCluster(ctx, destConfig, func(reflector *KubernetesReflectorPlugin) error {
+		return reflector.Delete(ctx, destConfig)
+	})
+}
+
+// SyncResource copies the TLS secret like Sync. For a cluster selector the
+// selected clusters are recorded as the resource name, and the secret is
+// deleted from recorded clusters that no longer match the selector.
+func (p *RemoteKubernetesPlugin) SyncResource(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig, resourceName string) (string, error) {
+	if destConfig.ClusterSelector == nil {
+		return "", p.Sync(ctx, sourceSecret, destConfig)
+	}
+
+	clusters, err := p.clustersFor(ctx, destConfig)
+	if err != nil {
+		return resourceName, err
+	}
+	syncErr := p.forClusters(ctx, clusters, func(reflector *KubernetesReflectorPlugin) error {
+		return reflector.Sync(ctx, sourceSecret, destConfig)
+	})
+
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		names = append(names, cluster.name)
+	}
+	// Clusters the secret could not be removed from stay recorded for the next sync
+	remaining, removeErr := p.removeFromClusters(ctx, destConfig, staleClusters(resourceName, names))
+	names = append(names, remaining...)
+
+	return strings.Join(names, ","), errors.Join(syncErr, removeErr)
+}
+
+// DeleteResource removes the reflected secret like Delete. For a cluster
+// selector it is also removed from the recorded clusters that no longer match.
+func (p *RemoteKubernetesPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, resourceName string) error {
+	if destConfig.ClusterSelector == nil {
+		return p.Delete(ctx, destConfig)
+	}
+
+	clusters, err := p.clustersFor(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	deleteErr := p.forClusters(ctx, clusters, func(reflector *KubernetesReflectorPlugin) error {
+		return reflector.Delete(ctx, destConfig)
+	})
+
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		names = append(names, cluster.name)
+	}
+	_, removeErr := p.removeFromClusters(ctx, destConfig, staleClusters(resourceName, names))
+
+	return errors.Join(deleteErr, removeErr)
+}
+
+// forEachCluster calls fn with a reflector for the configured remote cluster, or
+// for each selected Cluster API cluster.
+func (p *RemoteKubernetesPlugin) forEachCluster(ctx context.Context, destConfig certautov1.DestinationConfig, fn func(*KubernetesReflectorPlugin) error) error {
+	if destConfig.ClusterSelector == nil {
+		reflector, err := p.reflectorFor(ctx, destConfig.RemoteCluster)
+		if err != nil {
+			return err
+		}
+		return fn(reflector)
+	}
+
+	clusters, err := p.clustersFor(ctx, destConfig)
+	if err != nil {
+		return err
+	}
+	return p.forClusters(ctx, clusters, fn)
+}
+
+// clustersFor returns the Cluster API clusters selected by destConfig.
+func (p *RemoteKubernetesPlugin) clustersFor(ctx context.Context, destConfig certautov1.DestinationConfig) ([]selectedCluster, error) {
+	if destConfig.RemoteCluster != nil {
+		return nil, fmt.Errorf("remoteCluster and clusterSelector are mutually exclusive")
+	}
+	return p.selectedClusters(ctx, destConfig.ClusterSelector)
+}
+
+// forClusters calls fn with a reflector for each cluster. Failures on
+// individual clusters do not stop the others and are reported together.
+func (p *RemoteKubernetesPlugin) forClusters(ctx context.Context, clusters []selectedCluster, fn func(*KubernetesReflectorPlugin) error) error {
+	var failures []string
+	for _, cluster := range clusters {
+		reflector, err := p.reflectorFor(ctx, cluster.remote)
+		if err == nil {
+			err = fn(reflector)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", cluster.name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed on %d of %d clusters: %s", len(failures), len(clusters), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// removeFromClusters deletes the reflected secret from the named clusters and
+// returns the names of those it failed on. Clusters that no longer exist or
+// are being deleted are skipped and their cached client is dropped.
+func (p *RemoteKubernetesPlugin) removeFromClusters(ctx context.Context, destConfig certautov1.DestinationConfig, names []string) ([]string, error) {
+	logger := log.FromContext(ctx)
+
+	var remaining, failures []string
+	for _, name := range names {
+		cluster, err := p.recordedCluster(ctx, name)
+		if err == nil && cluster.remote == nil {
+			logger.Info("Cluster no longer exists, skipping", "cluster", name)
+			continue
+		}
+		if err == nil {
+			logger.Info("Cluster no longer selected, deleting reflected secret", "cluster", name)
+			err = p.forClusters(ctx, []selectedCluster{cluster}, func(reflector *KubernetesReflectorPlugin) error {
+				return reflector.Delete(ctx, destConfig)
+			})
+		}
+		if err != nil {
+			remaining = append(remaining, name)
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return remaining, fmt.Errorf("failed to delete from deselected clusters: %s", strings.Join(failures, "; "))
+	}
+
+	return remaining, nil
+}
+
+// evictClient drops the cached client of a cluster.
+func (p *RemoteKubernetesPlugin) evictClient(cacheKey string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, cacheKey)
+}
+
+// staleClusters returns the clusters recorded in resourceName that are not in names.
+func staleClusters(resourceName string, names []string) []string {
+	var stale []string
+	for _, name := range strings.Split(resourceName, ",") {
+		if name != "" && !slices.Contains(names, name) && !slices.Contains(stale, name) {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+// reflectorFor returns a reflector that writes to the remote cluster and reads
+// referenced secrets from the local cluster.
+func (p *RemoteKubernetesPlugin) reflectorFor(ctx context.Context, cluster *certautov1.RemoteCluster) (*KubernetesReflectorPlugin, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("remoteCluster is required for RemoteKubernetes destination")
+	}
+
+	restConfig, cacheKey, hash, err := p.remoteRESTConfig(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := p.remoteClient(restConfig, cacheKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KubernetesReflectorPlugin{Client: remote, ReferenceReader: p.Client}, nil
+}
+
+// remoteClient returns the cached client for cacheKey, building a new one when
+// the cluster is not cached yet or its credentials changed.
+func (p *RemoteKubernetesPlugin) remoteClient(restConfig *rest.Config, cacheKey, hash string) (client.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.clients[cacheKey]; ok && cached.credentialsHash == hash {
+		return cached.client, nil
+	}
+
+	remote, err := client.New(restConfig, client.Options{Scheme: p.Scheme()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote cluster client: %v", err)
+	}
+
+	if p.clients == nil {
+		p.clients = make(map[string]cachedRemoteClient)
+	}
+	p.clients[cacheKey] = cachedRemoteClient{credentialsHash: hash, client: remote}
+
+	return remote, nil
+}
+
+// remoteRESTConfig builds the REST config of the remote cluster and returns it
+// together with a key that identifies the cluster in the client cache and a
+// hash of the credentials it was built from.
+func (p *RemoteKubernetesPlugin) remoteRESTConfig(ctx context.Context, cluster *certautov1.RemoteCluster) (*rest.Config, string, string, error) {
+	var restConfig *rest.Config
+	var cacheKey string
+	var credentials [][]byte
+
+	switch {
+	case cluster.KubeconfigSecretRef != nil:
+		kubeconfig, err := readSecretKeyRef(ctx, p.Client, cluster.KubeconfigSecretRef)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to read kubeconfig: %v", err)
+		}
+		restConfig, err = restConfigFromKubeconfig(ctx, kubeconfig)
+		if err != nil {
+			return nil, "", "", err
+		}
+		cacheKey = kubeconfigCacheKey(ctx, cluster.KubeconfigSecretRef)
+		credentials = [][]byte{kubeconfig}
+	case cluster.Server != "" && cluster.TokenSecretRef != nil:
+		token, err := readSecretKeyRef(ctx, p.Client, cluster.TokenSecretRef)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to read token: %v", err)
+		}
+		restConfig = &rest.Config{
+			Host:        cluster.Server,
+			BearerToken: string(token),
+		}
+		if cluster.CASecretRef != nil {
+			ca, err := readSecretKeyRef(ctx, p.Client, cluster.CASecretRef)
+			if err != nil {
+				return nil, "", "", fmt.Errorf("failed to read API server CA: %v", err)
+			}
+			restConfig.CAData = ca
+		}
+		cacheKey = "token/" + cluster.Server + "/" + secretKeyRefID(ctx, cluster.TokenSecretRef)
+		credentials = [][]byte{[]byte(cluster.Server), token, restConfig.CAData}
+	default:
+		return nil, "", "", fmt.Errorf("remoteCluster requires kubeconfigSecretRef or server and tokenSecretRef")
+	}
+
+	restConfig.Timeout = remoteClusterTimeout
+	return restConfig, cacheKey, credentialsHash(credentials...), nil
+}
+
+// restConfigFromKubeconfig builds a REST config from kubeconfig. Credentials
+// must be inline, as file paths would be read from the controller's
+// filesystem. Exec and auth provider plugins run with the controller's
+// identity and may only be used by DestinationProvider configs.
+func restConfigFromKubeconfig(ctx context.Context, kubeconfig []byte) (*rest.Config, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+
+	for name, cluster := range config.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("kubeconfig cluster %s must use certificate-authority-data instead of certificate-authority", name)
+		}
+	}
+	for name, user := range config.AuthInfos {
+		if user.ClientCertificate != "" || user.ClientKey != "" || user.TokenFile != "" {
+			return nil, fmt.Errorf("kubeconfig user %s must use inline credentials instead of client-certificate, client-key or tokenFile", name)
+		}
+		if (user.Exec != nil || user.AuthProvider != nil) && bindingScope(ctx).Provider == "" {
+			return nil, fmt.Errorf("kubeconfig user %s: exec and auth-provider may only be used by DestinationProvider configs", name)
+		}
+	}
+
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+	return restConfig, nil
+}
+
+// kubeconfigCacheKey returns the client cache key of a cluster connected to with the kubeconfig in ref.
+func kubeconfigCacheKey(ctx context.Context, ref *certautov1.SecretKeyRef) string {
+	return "kubeconfig/" + secretKeyRefID(ctx, ref)
+}
+
+// secretKeyRefID returns a string identifying the value referenced by ref, with
+// the namespace resolved as in readSecretKeyRef.
+func secretKeyRefID(ctx context.Context, ref *certautov1.SecretKeyRef) string {
+	namespace, err := scopedNamespace(ctx, ref.Namespace)
+	if err != nil {
+		namespace = ref.Namespace
+	}
+	return fmt.Sprintf("%s/%s/%s", namespace, ref.Name, ref.Key)
+}
+
+// credentialsHash hashes the values that a remote cluster client was built from.
+func credentialsHash(values ...[]byte) string {
+	h := sha256.New()
+	for _, value := range values {
+		h.Write(value)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,46 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+)
+
+// bindingScopeKey is the context key of the BindingScope.
+type bindingScopeKey struct{}
+
+// BindingScope describes the CertificateBinding a destination is synced for.
+// Destination configs written by a binding's owner may only reference secrets
+// in the namespace of the binding. Configs of a DestinationProvider, which only
+// cluster administrators can create, may reference any namespace.
+type BindingScope struct {
+	// Namespace of the binding.
+	Namespace string
+
+	// Provider is the name of the DestinationProvider the config was read from, if any.
+	Provider string
+}
+
+// WithBindingScope returns a copy of ctx carrying scope.
+func WithBindingScope(ctx context.Context, scope BindingScope) context.Context {
+	return context.WithValue(ctx, bindingScopeKey{}, scope)
+}
+
+// bindingScope returns the BindingScope carried by ctx.
+func bindingScope(ctx context.Context) BindingScope {
+	scope, _ := ctx.Value(bindingScopeKey{}).(BindingScope)
+	return scope
+}
+
+// scopedNamespace returns the namespace of a resource referenced by a
+// destination config, defaulting to the namespace of the binding. Other
+// namespaces may only be referenced by DestinationProvider configs.
+func scopedNamespace(ctx context.Context, namespace string) (string, error) {
+	scope := bindingScope(ctx)
+	if namespace == "" {
+		return scope.Namespace, nil
+	}
+	if namespace != scope.Namespace && scope.Provider == "" {
+		return "", fmt.Errorf("namespace %s is not the namespace of the binding, only DestinationProvider configs may reference other namespaces", namespace)
+	}
+	return namespace, nil
+}
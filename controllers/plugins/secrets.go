@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// readSecretKeyRef returns the value referenced by ref. The namespace of the
+// referenced secret defaults to the namespace of the binding and is restricted
+// to it unless the config comes from a DestinationProvider.
+func readSecretKeyRef(ctx context.Context, c client.Reader, ref *certautov1.SecretKeyRef) ([]byte, error) {
+	namespace, err := scopedNamespace(ctx, ref.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: %v", ref.Name, err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %v", namespace, ref.Name, err)
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in secret %s/%s", ref.Key, namespace, ref.Name)
+	}
+	return value, nil
+}
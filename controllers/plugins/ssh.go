@@ -0,0 +1,403 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// sshDeployedFile records the fingerprint of the certificate the post-deploy
+	// command last succeeded for, so a failed command is retried.
+	sshDeployedFile = ".certauto-deployed"
+
+	// sshDialTimeout is the timeout for connecting to the host.
+	sshDialTimeout = 30 * time.Second
+)
+
+// SSHPlugin writes certificate files to a directory on a remote host over SFTP,
+// for hosts outside Kubernetes such as bare-metal web servers. Files are only
+// replaced when their content changes, and the optional post-deploy command
+// runs after a change.
+type SSHPlugin struct {
+	client.Client
+}
+
+// sshFile is a file written to the remote directory.
+type sshFile struct {
+	name string
+	data []byte
+	mode os.FileMode
+}
+
+// Name returns the plugin name.
+func (p *SSHPlugin) Name() string {
+	return "SSH"
+}
+
+// Sync writes the certificate files and runs the post-deploy command when they changed.
+func (p *SSHPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	target := destConfig.SSH
+	if target == nil || target.Host == "" || target.Directory == "" {
+		return fmt.Errorf("ssh host and directory are required for SSH destination")
+	}
+	fileMode, err := parseFileMode(target.FileMode, 0o644)
+	if err != nil {
+		return err
+	}
+	keyMode, err := parseFileMode(target.PrivateKeyFileMode, 0o600)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+
+	sshClient, err := p.dial(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp session: %v", err)
+	}
+	defer sftpClient.Close()
+
+	if err := sftpClient.MkdirAll(target.Directory); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", target.Directory, err)
+	}
+
+	names := sshFileNames(target.Files)
+	existingKey, err := readRemoteFile(sftpClient, path.Join(target.Directory, names.PrivateKey))
+	if err != nil {
+		return err
+	}
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, existingKey)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, file := range []sshFile{
+		{name: names.Certificate, data: encodeCertificatesPEM(bundle.Chain()[:1]), mode: fileMode},
+		{name: names.Chain, data: encodeCertificatesPEM(bundle.CABundle()), mode: fileMode},
+		{name: names.FullChain, data: encodeCertificatesPEM(bundle.FullChain()), mode: fileMode},
+		{name: names.PrivateKey, data: keyBytes, mode: keyMode},
+	} {
+		written, err := writeRemoteFile(sftpClient, path.Join(target.Directory, file.name), file.data, file.mode, target)
+		if err != nil {
+			return err
+		}
+		if written {
+			logger.Info("Wrote certificate file over SFTP", "host", target.Host, "file", path.Join(target.Directory, file.name))
+		}
+		changed = changed || written
+	}
+
+	if target.PostDeployCommand == "" {
+		return nil
+	}
+	fingerprint := certificateFingerprint(bundle.Leaf)
+	deployed, err := readRemoteFile(sftpClient, path.Join(target.Directory, sshDeployedFile))
+	if err != nil {
+		return err
+	}
+	if !changed && string(deployed) == fingerprint {
+		return nil
+	}
+
+	logger.Info("Running post-deploy command", "host", target.Host)
+	if err := runSSHCommand(sshClient, target.PostDeployCommand); err != nil {
+		return err
+	}
+	if _, err := writeRemoteFile(sftpClient, path.Join(target.Directory, sshDeployedFile), []byte(fingerprint), fileMode, target); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckExists checks if the certificate file exists on the host.
+func (p *SSHPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	target := destConfig.SSH
+	if target == nil || target.Host == "" || target.Directory == "" {
+		return false, nil
+	}
+
+	sshClient, err := p.dial(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	defer sshClient.Close()
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return false, fmt.Errorf("failed to start sftp session: %v", err)
+	}
+	defer sftpClient.Close()
+
+	_, err = sftpClient.Stat(path.Join(target.Directory, sshFileNames(target.Files).Certificate))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the certificate files from the host. The directory and the
+// post-deploy command are left alone.
+func (p *SSHPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	target := destConfig.SSH
+	if target == nil || target.Host == "" || target.Directory == "" {
+		return nil
+	}
+
+	sshClient, err := p.dial(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("failed to start sftp session: %v", err)
+	}
+	defer sftpClient.Close()
+
+	names := sshFileNames(target.Files)
+	for _, name := range []string{names.Certificate, names.Chain, names.FullChain, names.PrivateKey, sshDeployedFile} {
+		if err := sftpClient.Remove(path.Join(target.Directory, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// dial connects to the host with the configured key, verifying the host key
+// against the known_hosts entries.
+func (p *SSHPlugin) dial(ctx context.Context, target *certautov1.SSHTarget) (*ssh.Client, error) {
+	keyPEM, err := readSecretKeyRef(ctx, p.Client, &target.PrivateKeySecretRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh private key: %v", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh private key: %v", err)
+	}
+
+	knownHosts, err := readSecretKeyRef(ctx, p.Client, &target.KnownHostsSecretRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts: %v", err)
+	}
+	hostKeyCallback, err := knownHostsCallback(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := target.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	config := &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}
+
+	dialer := &net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to establish ssh connection to %s: %v", addr, err)
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// knownHostsCallback returns a host key callback for known_hosts entries.
+func knownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	// knownhosts only reads files
+	file, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write known_hosts: %v", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if _, err := file.Write(knownHosts); err != nil {
+		return nil, fmt.Errorf("failed to write known_hosts: %v", err)
+	}
+
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse known_hosts: %v", err)
+	}
+	return callback, nil
+}
+
+// runSSHCommand runs a command on the host and fails on a non-zero exit status.
+func runSSHCommand(sshClient *ssh.Client, command string) error {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open ssh session: %v", err)
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput(command)
+	if err != nil {
+		return fmt.Errorf("post-deploy command failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// readRemoteFile returns the content of a remote file, or nil if it does not exist.
+func readRemoteFile(sftpClient *sftp.Client, name string) ([]byte, error) {
+	file, err := sftpClient.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", name, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	return data, nil
+}
+
+// writeRemoteFile replaces a remote file when its content differs and keeps
+// its mode and owner up to date. It reports whether the content was written.
+// The new content is written to a temporary file that is renamed over the
+// old one, so readers never see a partial file.
+func writeRemoteFile(sftpClient *sftp.Client, name string, data []byte, mode os.FileMode, target *certautov1.SSHTarget) (bool, error) {
+	current, err := readRemoteFile(sftpClient, name)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && bytes.Equal(current, data) {
+		return false, setRemoteFileAttributes(sftpClient, name, mode, target)
+	}
+
+	tmp := name + ".certauto-tmp"
+	file, err := sftpClient.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return false, fmt.Errorf("failed to create %s: %v", tmp, err)
+	}
+	// Restrict the mode before writing so the key is never readable by others
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return false, fmt.Errorf("failed to set mode of %s: %v", tmp, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return false, fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := file.Close(); err != nil {
+		return false, fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := setRemoteFileAttributes(sftpClient, tmp, mode, target); err != nil {
+		return false, err
+	}
+
+	if err := sftpClient.PosixRename(tmp, name); err != nil {
+		// Servers without the posix-rename extension refuse to overwrite
+		if err := sftpClient.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to replace %s: %v", name, err)
+		}
+		if err := sftpClient.Rename(tmp, name); err != nil {
+			return false, fmt.Errorf("failed to replace %s: %v", name, err)
+		}
+	}
+	return true, nil
+}
+
+// setRemoteFileAttributes sets the mode and configured owner of a remote file
+// if they differ.
+func setRemoteFileAttributes(sftpClient *sftp.Client, name string, mode os.FileMode, target *certautov1.SSHTarget) error {
+	info, err := sftpClient.Stat(name)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", name, err)
+	}
+	if info.Mode().Perm() != mode {
+		if err := sftpClient.Chmod(name, mode); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %v", name, err)
+		}
+	}
+
+	if target.UID == nil && target.GID == nil {
+		return nil
+	}
+	uid, gid := -1, -1
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		uid, gid = int(stat.UID), int(stat.GID)
+	}
+	wantUID, wantGID := uid, gid
+	if target.UID != nil {
+		wantUID = int(*target.UID)
+	}
+	if target.GID != nil {
+		wantGID = int(*target.GID)
+	}
+	if wantUID != uid || wantGID != gid {
+		if err := sftpClient.Chown(name, wantUID, wantGID); err != nil {
+			return fmt.Errorf("failed to set owner of %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// sshFileNames returns the configured file names with defaults applied.
+func sshFileNames(files *certautov1.SSHFiles) certautov1.SSHFiles {
+	names := certautov1.SSHFiles{
+		Certificate: "cert.pem",
+		PrivateKey:  "privkey.pem",
+		Chain:       "chain.pem",
+		FullChain:   "fullchain.pem",
+	}
+	if files != nil {
+		names.Certificate = defaultString(files.Certificate, names.Certificate)
+		names.PrivateKey = defaultString(files.PrivateKey, names.PrivateKey)
+		names.Chain = defaultString(files.Chain, names.Chain)
+		names.FullChain = defaultString(files.FullChain, names.FullChain)
+	}
+	return names
+}
+
+// parseFileMode parses an octal file mode, returning def if it is empty.
+func parseFileMode(value string, def os.FileMode) (os.FileMode, error) {
+	if value == "" {
+		return def, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q", value)
+	}
+	return os.FileMode(mode), nil
+}
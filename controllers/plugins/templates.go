@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// templateData is the data passed to destination data templates.
+type templateData struct {
+	// Certificate is tls.crt as stored in the source secret.
+	Certificate string
+	// Leaf is the PEM encoded leaf certificate.
+	Leaf string
+	// Chain is the PEM encoded intermediate certificates.
+	Chain string
+	// FullChain is the PEM encoded leaf, intermediate and CA certificates.
+	FullChain string
+	// CA is ca.crt as stored in the source secret.
+	CA string
+	// PrivateKey is the private key encoded as configured for the destination.
+	PrivateKey string
+	// PrivateKeyPKCS8 is the PEM encoded private key in PKCS#8 form, encrypted
+	// when a passphrase is configured.
+	PrivateKeyPKCS8 string
+
+	CommonName   string
+	DNSNames     []string
+	SerialNumber string
+	// Fingerprint is the hex encoded SHA-256 fingerprint of the leaf certificate.
+	Fingerprint string
+	NotBefore   time.Time
+	NotAfter    time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"join":   func(sep string, elems []string) string { return strings.Join(elems, sep) },
+}
+
+// newTemplateData builds the template data from a TLS secret. keyPEM is the
+// private key as encoded for the destination; the private key fields are left
+// empty when it is nil. An encrypted key is used for both private key fields.
+func newTemplateData(secret *corev1.Secret, keyPEM []byte, encrypted bool) (*templateData, error) {
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &templateData{
+		Certificate:  string(secret.Data["tls.crt"]),
+		Leaf:         string(encodeCertificatesPEM(bundle.Chain()[:1])),
+		Chain:        string(encodeCertificatesPEM(bundle.Intermediates)),
+		FullChain:    string(encodeCertificatesPEM(bundle.FullChain())),
+		CA:           string(secret.Data["ca.crt"]),
+		CommonName:   bundle.Leaf.Subject.CommonName,
+		DNSNames:     bundle.Leaf.DNSNames,
+		SerialNumber: bundle.Leaf.SerialNumber.String(),
+		Fingerprint:  certificateFingerprint(bundle.Leaf),
+		NotBefore:    bundle.Leaf.NotBefore,
+		NotAfter:     bundle.Leaf.NotAfter,
+	}
+
+	if keyPEM != nil {
+		data.PrivateKey = string(keyPEM)
+		// Encrypted keys are always PKCS#8
+		data.PrivateKeyPKCS8 = string(keyPEM)
+		if !encrypted {
+			keyDER, err := x509.MarshalPKCS8PrivateKey(bundle.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode private key as PKCS#8: %v", err)
+			}
+			data.PrivateKeyPKCS8 = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+		}
+	}
+
+	return data, nil
+}
+
+// renderDataTemplates renders each template in templates and stores the result
+// in data. keyPEM and encrypted are as for newTemplateData.
+func renderDataTemplates(sourceSecret *corev1.Secret, templates map[string]string, keyPEM []byte, encrypted bool, data map[string][]byte) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	values, err := newTemplateData(sourceSecret, keyPEM, encrypted)
+	if err != nil {
+		return err
+	}
+
+	for key, text := range templates {
+		tmpl, err := template.New(key).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("failed to parse template for %s: %v", key, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return fmt.Errorf("failed to render template for %s: %v", key, err)
+		}
+		data[key] = buf.Bytes()
+	}
+
+	return nil
+}
@@ -0,0 +1,416 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	vault "github.com/hashicorp/vault/api"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// defaultVaultMount is the default mount path of the KV secrets engine.
+	defaultVaultMount = "secret"
+
+	// defaultVaultPath is the default template for the secret path within the mount.
+	defaultVaultPath = "{{ .Namespace }}/{{ .Name }}"
+
+	// vaultTokenAudience is the audience of service account tokens sent to Vault.
+	// It is fixed so that the tokens are never accepted by the API server.
+	vaultTokenAudience = "vault"
+
+	// vaultTokenExpirationSeconds is the lifetime of service account tokens sent to Vault.
+	vaultTokenExpirationSeconds = 600
+)
+
+// AllowVaultTokenAnnotation must be set to "true" on a service account before
+// tokens are requested for it to log in to Vault.
+const AllowVaultTokenAnnotation = "certauto.sanorg.in/allow-vault-token"
+
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+
+// VaultKVPlugin writes certificates to a Vault KV secrets engine, version 1 or 2.
+// On version 2 mounts the fingerprint and source of the certificate are
+// recorded in the custom metadata of the secret, and a new version is only
+// written when the data changes.
+type VaultKVPlugin struct {
+	client.Client
+}
+
+// vaultPathData is the data passed to the path template.
+type vaultPathData struct {
+	Namespace  string
+	Name       string
+	CommonName string
+}
+
+// Name returns the plugin name.
+func (p *VaultKVPlugin) Name() string {
+	return "VaultKV"
+}
+
+// ResourceName returns the rendered secret path, which is recorded so that the
+// secret can be found again when the path depends on the source secret.
+func (p *VaultKVPlugin) ResourceName(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	if destConfig.Vault == nil {
+		return ""
+	}
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return ""
+	}
+	path, err := vaultSecretPath(destConfig.Vault, newVaultPathData(secret, bundle))
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// Sync writes the certificate, private key and chain to the configured path.
+func (p *VaultKVPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+
+	kv := destConfig.Vault
+	if kv == nil {
+		return fmt.Errorf("vault is required for VaultKV destination")
+	}
+	path, err := vaultSecretPath(kv, newVaultPathData(secret, bundle))
+	if err != nil {
+		return err
+	}
+
+	vc, err := p.login(ctx, kv)
+	if err != nil {
+		return err
+	}
+	defer p.revoke(ctx, vc)
+
+	existing, err := readVaultSecret(ctx, vc, kv, path)
+	if err != nil {
+		return err
+	}
+
+	var existingKey []byte
+	if key, ok := existing["private_key"].(string); ok {
+		existingKey = []byte(key)
+	}
+	keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, existingKey)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"certificate": string(encodeCertificatesPEM(bundle.Chain()[:1])),
+		"private_key": string(keyBytes),
+		"chain":       string(encodeCertificatesPEM(bundle.FullChain()[1:])),
+	}
+
+	if maps.EqualFunc(existing, data, func(a, b interface{}) bool { return a == b }) {
+		logger.V(1).Info("Vault secret is up to date", "mount", vaultMount(kv), "path", path)
+	} else {
+		logger.Info("Writing certificate to Vault", "mount", vaultMount(kv), "path", path)
+		if kv.Version == 1 {
+			err = vc.KVv1(vaultMount(kv)).Put(ctx, path, data)
+		} else {
+			_, err = vc.KVv2(vaultMount(kv)).Put(ctx, path, data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write vault secret: %v", err)
+		}
+	}
+
+	if kv.Version == 1 {
+		return nil
+	}
+	return writeVaultMetadata(ctx, vc, kv, path, secret, bundle)
+}
+
+// CheckExists checks if the secret exists in Vault. The path must not depend on the source secret.
+func (p *VaultKVPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	return p.CheckResourceExists(ctx, destConfig, "")
+}
+
+// CheckResourceExists checks if the secret at the recorded path exists in
+// Vault. Without a recorded path, the path must not depend on the source secret.
+func (p *VaultKVPlugin) CheckResourceExists(ctx context.Context, destConfig certautov1.DestinationConfig, path string) (bool, error) {
+	kv := destConfig.Vault
+	if kv == nil {
+		return false, fmt.Errorf("vault is required for VaultKV destination")
+	}
+	path, err := recordedVaultPath(kv, path)
+	if err != nil {
+		return false, err
+	}
+
+	vc, err := p.login(ctx, kv)
+	if err != nil {
+		return false, err
+	}
+	defer p.revoke(ctx, vc)
+
+	existing, err := readVaultSecret(ctx, vc, kv, path)
+	if err != nil {
+		return false, err
+	}
+	return existing != nil, nil
+}
+
+// Delete deletes the secret from Vault. The path must not depend on the source secret.
+func (p *VaultKVPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource deletes the secret at the recorded path from Vault, including
+// all versions and metadata on KV version 2. Without a recorded path, the path
+// must not depend on the source secret.
+func (p *VaultKVPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, path string) error {
+	kv := destConfig.Vault
+	if kv == nil {
+		return fmt.Errorf("vault is required for VaultKV destination")
+	}
+	path, err := recordedVaultPath(kv, path)
+	if err != nil {
+		return err
+	}
+
+	vc, err := p.login(ctx, kv)
+	if err != nil {
+		return err
+	}
+	defer p.revoke(ctx, vc)
+
+	if kv.Version == 1 {
+		err = vc.KVv1(vaultMount(kv)).Delete(ctx, path)
+	} else {
+		err = vc.KVv2(vaultMount(kv)).DeleteMetadata(ctx, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete vault secret: %v", err)
+	}
+	return nil
+}
+
+// login returns a Vault client authenticated with the configured auth method.
+func (p *VaultKVPlugin) login(ctx context.Context, kv *certautov1.VaultKV) (*vault.Client, error) {
+	config := vault.DefaultConfig()
+	if config.Error != nil {
+		return nil, fmt.Errorf("failed to configure vault client: %v", config.Error)
+	}
+	config.Address = kv.Address
+	if kv.CABundleSecretRef != nil {
+		ca, err := readSecretKeyRef(ctx, p.Client, kv.CABundleSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault CA bundle: %v", err)
+		}
+		if err := config.ConfigureTLS(&vault.TLSConfig{CACertBytes: ca}); err != nil {
+			return nil, fmt.Errorf("failed to configure vault TLS: %v", err)
+		}
+	}
+
+	vc, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %v", err)
+	}
+	vc.ClearToken()
+	if kv.Namespace != "" {
+		vc.SetNamespace(kv.Namespace)
+	}
+
+	var mountPath string
+	var params map[string]interface{}
+	switch {
+	case kv.Auth.Kubernetes != nil && kv.Auth.AppRole != nil:
+		return nil, fmt.Errorf("vault auth methods kubernetes and appRole are mutually exclusive")
+	case kv.Auth.Kubernetes != nil:
+		// The token minted by the controller is sent to the address, so both must
+		// be chosen by a cluster administrator
+		if bindingScope(ctx).Provider == "" {
+			return nil, fmt.Errorf("vault kubernetes auth may only be used by DestinationProvider configs")
+		}
+		jwt, err := p.serviceAccountToken(ctx, kv.Auth.Kubernetes)
+		if err != nil {
+			return nil, err
+		}
+		mountPath = defaultString(kv.Auth.Kubernetes.MountPath, "kubernetes")
+		params = map[string]interface{}{
+			"role": kv.Auth.Kubernetes.Role,
+			"jwt":  jwt,
+		}
+	case kv.Auth.AppRole != nil:
+		secretID, err := readSecretKeyRef(ctx, p.Client, &kv.Auth.AppRole.SecretIDSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read approle secret ID: %v", err)
+		}
+		mountPath = defaultString(kv.Auth.AppRole.MountPath, "approle")
+		params = map[string]interface{}{
+			"role_id":   kv.Auth.AppRole.RoleID,
+			"secret_id": strings.TrimSpace(string(secretID)),
+		}
+	default:
+		return nil, fmt.Errorf("vault auth method is required for VaultKV destination")
+	}
+
+	auth, err := vc.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", strings.Trim(mountPath, "/")), params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in to vault: %v", err)
+	}
+	if auth == nil || auth.Auth == nil || auth.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("failed to log in to vault: no client token returned")
+	}
+	vc.SetToken(auth.Auth.ClientToken)
+
+	return vc, nil
+}
+
+// serviceAccountToken requests a short lived token for the configured service
+// account in the namespace of the binding, so that Vault never receives the
+// token of the controller itself. The service account must opt in with
+// AllowVaultTokenAnnotation.
+func (p *VaultKVPlugin) serviceAccountToken(ctx context.Context, auth *certautov1.VaultKubernetesAuth) (string, error) {
+	if auth.ServiceAccountName == "" {
+		return "", fmt.Errorf("vault kubernetes auth requires serviceAccountName")
+	}
+	serviceAccount := &corev1.ServiceAccount{}
+	key := types.NamespacedName{Name: auth.ServiceAccountName, Namespace: bindingScope(ctx).Namespace}
+	if err := p.Get(ctx, key, serviceAccount); err != nil {
+		return "", fmt.Errorf("failed to get service account %s: %v", key, err)
+	}
+	if serviceAccount.Annotations[AllowVaultTokenAnnotation] != "true" {
+		return "", fmt.Errorf("service account %s is not annotated with %s: \"true\"", key, AllowVaultTokenAnnotation)
+	}
+
+	expirationSeconds := int64(vaultTokenExpirationSeconds)
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{vaultTokenAudience},
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+	if err := p.SubResource("token").Create(ctx, serviceAccount, request); err != nil {
+		return "", fmt.Errorf("failed to request token for service account %s/%s: %v", serviceAccount.Namespace, serviceAccount.Name, err)
+	}
+	return request.Status.Token, nil
+}
+
+// revoke revokes the token of a client returned by login. Tokens are short
+// lived, so failures are only logged.
+func (p *VaultKVPlugin) revoke(ctx context.Context, vc *vault.Client) {
+	if err := vc.Auth().Token().RevokeSelfWithContext(ctx, ""); err != nil {
+		log.FromContext(ctx).V(1).Info("Failed to revoke vault token", "error", err.Error())
+	}
+}
+
+// readVaultSecret returns the data at path, or nil if there is no secret.
+func readVaultSecret(ctx context.Context, vc *vault.Client, kv *certautov1.VaultKV, path string) (map[string]interface{}, error) {
+	var secret *vault.KVSecret
+	var err error
+	if kv.Version == 1 {
+		secret, err = vc.KVv1(vaultMount(kv)).Get(ctx, path)
+	} else {
+		secret, err = vc.KVv2(vaultMount(kv)).Get(ctx, path)
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret: %v", err)
+	}
+	return secret.Data, nil
+}
+
+// writeVaultMetadata sets max_versions and custom_metadata on a KV version 2
+// secret. Metadata fields that are not sent keep their current values.
+func writeVaultMetadata(ctx context.Context, vc *vault.Client, kv *certautov1.VaultKV, path string, secret *corev1.Secret, bundle *certificateBundle) error {
+	customMetadata := map[string]interface{}{}
+	for key, value := range kv.CustomMetadata {
+		customMetadata[key] = value
+	}
+	customMetadata["fingerprint"] = certificateFingerprint(bundle.Leaf)
+	customMetadata["source-namespace"] = secret.Namespace
+	customMetadata["source-name"] = secret.Name
+
+	metadata := map[string]interface{}{
+		"custom_metadata": customMetadata,
+	}
+	if kv.MaxVersions != nil {
+		metadata["max_versions"] = *kv.MaxVersions
+	}
+
+	metadataPath := fmt.Sprintf("%s/metadata/%s", vaultMount(kv), path)
+	if _, err := vc.Logical().WriteWithContext(ctx, metadataPath, metadata); err != nil {
+		return fmt.Errorf("failed to write vault secret metadata: %v", err)
+	}
+	return nil
+}
+
+// newVaultPathData returns the path template data of a source secret.
+func newVaultPathData(secret *corev1.Secret, bundle *certificateBundle) *vaultPathData {
+	return &vaultPathData{
+		Namespace:  secret.Namespace,
+		Name:       secret.Name,
+		CommonName: bundle.Leaf.Subject.CommonName,
+	}
+}
+
+// recordedVaultPath returns the recorded path, or the path template rendered
+// without a source secret.
+func recordedVaultPath(kv *certautov1.VaultKV, path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return vaultSecretPath(kv, nil)
+}
+
+// vaultSecretPath renders the path template. A nil data only renders paths
+// that do not reference the source secret.
+func vaultSecretPath(kv *certautov1.VaultKV, data *vaultPathData) (string, error) {
+	text := defaultString(kv.Path, defaultVaultPath)
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse vault path template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if data == nil {
+			return "", fmt.Errorf("vault path %q depends on the source secret", text)
+		}
+		return "", fmt.Errorf("failed to render vault path template: %v", err)
+	}
+
+	path := strings.Trim(buf.String(), "/")
+	if path == "" {
+		return "", fmt.Errorf("vault path template %q rendered an empty path", text)
+	}
+	return path, nil
+}
+
+// vaultMount returns the mount path of the KV secrets engine.
+func vaultMount(kv *certautov1.VaultKV) string {
+	return strings.Trim(defaultString(kv.Mount, defaultVaultMount), "/")
+}
+
+// defaultString returns value, or def if value is empty.
+func defaultString(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,291 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// VersionedFromLabel is set on every generation of a versioned secret to the
+	// name of its pointer secret.
+	VersionedFromLabel = "certauto.sanorg.in/versioned-from"
+
+	// CurrentGenerationAnnotation is set on the pointer secret to the name of the current generation.
+	CurrentGenerationAnnotation = "certauto.sanorg.in/current-generation"
+
+	// supersededAtAnnotation records when a generation stopped being current.
+	supersededAtAnnotation = "certauto.sanorg.in/superseded-at"
+
+	// currentGenerationKey is the pointer secret key holding the name of the current generation.
+	currentGenerationKey = "secretName"
+
+	// defaultGenerationGracePeriod is how long superseded generations are kept by default.
+	defaultGenerationGracePeriod = 24 * time.Hour
+)
+
+// syncVersioned writes the target data to an immutable secret named after its
+// content hash, points the target secret at it and deletes generations that
+// have been superseded for longer than the grace period.
+func (p *KubernetesReflectorPlugin) syncVersioned(ctx context.Context, sourceSecret *corev1.Secret, destConfig certautov1.DestinationConfig, namespace, name string) error {
+	logger := log.FromContext(ctx)
+
+	// Reuse the current generation so salted encodings keep the same hash
+	var existingData map[string][]byte
+	pointer := &corev1.Secret{}
+	if err := p.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, pointer); err == nil {
+		current := &corev1.Secret{}
+		currentName := pointer.Annotations[CurrentGenerationAnnotation]
+		if currentName != "" {
+			if err := p.Get(ctx, types.NamespacedName{Name: currentName, Namespace: namespace}, current); err == nil {
+				existingData = current.Data
+			} else if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get current generation: %v", err)
+			}
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check existing secret: %v", err)
+	}
+
+	secretType, data, err := p.targetSecretData(ctx, sourceSecret, destConfig, existingData)
+	if err != nil {
+		return err
+	}
+	generationName := fmt.Sprintf("%s-%s", name, secretContentHash(secretType, data))
+
+	labels, annotations := reflectedSecretMetadata(sourceSecret, destConfig.SecretTemplate)
+	labels[VersionedFromLabel] = name
+
+	generation := &corev1.Secret{}
+	err = p.Get(ctx, types.NamespacedName{Name: generationName, Namespace: namespace}, generation)
+	switch {
+	case errors.IsNotFound(err):
+		immutable := true
+		generation = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        generationName,
+				Namespace:   namespace,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Type:      secretType,
+			Data:      data,
+			Immutable: &immutable,
+		}
+		logger.Info("Creating secret generation",
+			"targetNamespace", namespace,
+			"targetSecret", generationName)
+		if err := p.Create(ctx, generation); err != nil {
+			return fmt.Errorf("failed to create secret generation: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to check secret generation: %v", err)
+	case generation.Annotations[supersededAtAnnotation] != "":
+		// Rolling back to an earlier generation makes it current again
+		logger.Info("Reusing earlier secret generation",
+			"targetNamespace", namespace,
+			"targetSecret", generationName)
+		delete(generation.Annotations, supersededAtAnnotation)
+		if err := p.Update(ctx, generation); err != nil {
+			return fmt.Errorf("failed to update secret generation: %v", err)
+		}
+	}
+
+	if err := p.syncGenerationPointer(ctx, namespace, name, generationName, labels, annotations); err != nil {
+		return err
+	}
+
+	return p.pruneGenerations(ctx, namespace, name, generationName, GenerationGracePeriod(destConfig))
+}
+
+// syncGenerationPointer points the stable target secret at the current generation.
+func (p *KubernetesReflectorPlugin) syncGenerationPointer(ctx context.Context, namespace, name, generationName string, labels, annotations map[string]string) error {
+	logger := log.FromContext(ctx)
+
+	pointer := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// A target secret written in place has a different type and must be replaced
+	existing := &corev1.Secret{}
+	if err := p.Get(ctx, client.ObjectKeyFromObject(pointer), existing); err == nil && existing.Type != corev1.SecretTypeOpaque {
+		logger.Info("Replacing target secret with generation pointer",
+			"targetNamespace", namespace,
+			"targetSecret", name)
+		if err := p.Client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret: %v", err)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check existing secret: %v", err)
+	}
+
+	pointerLabels := maps.Clone(labels)
+	delete(pointerLabels, VersionedFromLabel)
+	pointerAnnotations := maps.Clone(annotations)
+	pointerAnnotations[CurrentGenerationAnnotation] = generationName
+
+	result, err := controllerutil.CreateOrUpdate(ctx, p.Client, pointer, func() error {
+		mergeMetadata(pointer, pointerLabels, pointerAnnotations)
+		pointer.Type = corev1.SecretTypeOpaque
+		pointer.Data = map[string][]byte{currentGenerationKey: []byte(generationName)}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync generation pointer: %v", err)
+	}
+
+	logger.Info("Synced generation pointer",
+		"targetNamespace", namespace,
+		"targetSecret", name,
+		"currentGeneration", generationName,
+		"result", result)
+	return nil
+}
+
+// pruneGenerations marks generations other than current as superseded and
+// deletes those superseded for longer than gracePeriod. Generations still
+// referenced by a pod in the namespace are kept until the pod is replaced.
+func (p *KubernetesReflectorPlugin) pruneGenerations(ctx context.Context, namespace, name, current string, gracePeriod time.Duration) error {
+	logger := log.FromContext(ctx)
+
+	var generations corev1.SecretList
+	if err := p.List(ctx, &generations, client.InNamespace(namespace), client.MatchingLabels{VersionedFromLabel: name}); err != nil {
+		return fmt.Errorf("failed to list secret generations: %v", err)
+	}
+
+	var inUse map[string]bool
+
+	now := time.Now()
+	for i := range generations.Items {
+		generation := &generations.Items[i]
+		if generation.Name == current {
+			continue
+		}
+
+		supersededAt, err := time.Parse(time.RFC3339, generation.Annotations[supersededAtAnnotation])
+		if err != nil {
+			if generation.Annotations == nil {
+				generation.Annotations = map[string]string{}
+			}
+			generation.Annotations[supersededAtAnnotation] = now.UTC().Format(time.RFC3339)
+			if err := p.Update(ctx, generation); err != nil {
+				return fmt.Errorf("failed to mark secret generation %s as superseded: %v", generation.Name, err)
+			}
+			continue
+		}
+
+		if now.Sub(supersededAt) < gracePeriod {
+			continue
+		}
+		if inUse == nil {
+			if inUse, err = p.podSecretReferences(ctx, namespace); err != nil {
+				return err
+			}
+		}
+		if inUse[generation.Name] {
+			logger.Info("Keeping superseded secret generation used by a pod",
+				"targetNamespace", namespace,
+				"targetSecret", generation.Name)
+			continue
+		}
+		logger.Info("Deleting superseded secret generation",
+			"targetNamespace", namespace,
+			"targetSecret", generation.Name)
+		if err := p.Client.Delete(ctx, generation); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret generation %s: %v", generation.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// podSecretReferences returns the names of the secrets referenced by volumes
+// and environment variables of the pods in namespace.
+func (p *KubernetesReflectorPlugin) podSecretReferences(ctx context.Context, namespace string) (map[string]bool, error) {
+	var pods corev1.PodList
+	if err := p.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Secret != nil {
+				names[volume.Secret.SecretName] = true
+			}
+			if volume.Projected != nil {
+				for _, source := range volume.Projected.Sources {
+					if source.Secret != nil {
+						names[source.Secret.Name] = true
+					}
+				}
+			}
+		}
+		containers := slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers)
+		for _, container := range containers {
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					names[envFrom.SecretRef.Name] = true
+				}
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					names[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// deleteVersioned removes the pointer secret and every generation.
+func (p *KubernetesReflectorPlugin) deleteVersioned(ctx context.Context, namespace, name string) error {
+	var generations corev1.SecretList
+	if err := p.List(ctx, &generations, client.InNamespace(namespace), client.MatchingLabels{VersionedFromLabel: name}); err != nil {
+		return fmt.Errorf("failed to list secret generations: %v", err)
+	}
+	for i := range generations.Items {
+		if err := p.Client.Delete(ctx, &generations.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret generation %s: %v", generations.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// GenerationGracePeriod returns the configured grace period for superseded generations.
+func GenerationGracePeriod(destConfig certautov1.DestinationConfig) time.Duration {
+	if destConfig.Versioned.GracePeriod != nil {
+		return destConfig.Versioned.GracePeriod.Duration
+	}
+	return defaultGenerationGracePeriod
+}
+
+// secretContentHash returns a short hash of a secret's type and data.
+func secretContentHash(secretType corev1.SecretType, data map[string][]byte) string {
+	h := sha256.New()
+	h.Write([]byte(secretType))
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write(data[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:10]
+}
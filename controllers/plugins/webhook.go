@@ -0,0 +1,338 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// webhookSignatureHeader carries the HMAC-SHA256 signature of the request.
+	webhookSignatureHeader = "X-Certauto-Signature"
+
+	// webhookTimestampHeader carries the Unix timestamp included in the signature.
+	webhookTimestampHeader = "X-Certauto-Timestamp"
+
+	// defaultWebhookTimeout is the default timeout of each request.
+	defaultWebhookTimeout = 30 * time.Second
+)
+
+// WebhookPlugin sends certificates to an HTTP endpoint, for appliances and
+// services without a dedicated destination. Receivers should use the
+// fingerprint to ignore certificates they already have, as the certificate is
+// sent again on every sync.
+type WebhookPlugin struct {
+	client.Client
+}
+
+// webhookPayload is the JSON body sent on sync and delete.
+type webhookPayload struct {
+	Event       string   `json:"event"`
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+	Certificate string   `json:"certificate,omitempty"`
+	Chain       string   `json:"chain,omitempty"`
+	CA          string   `json:"ca,omitempty"`
+	PrivateKey  string   `json:"privateKey,omitempty"`
+}
+
+// Name returns the plugin name.
+func (p *WebhookPlugin) Name() string {
+	return "Webhook"
+}
+
+// ResourceName returns the namespace and name of the source secret, which the
+// receiver is told to delete.
+func (p *WebhookPlugin) ResourceName(secret *corev1.Secret, destConfig certautov1.DestinationConfig) string {
+	return secret.Namespace + "/" + secret.Name
+}
+
+// Sync POSTs the certificate material to the webhook URL.
+func (p *WebhookPlugin) Sync(ctx context.Context, secret *corev1.Secret, destConfig certautov1.DestinationConfig) error {
+	logger := log.FromContext(ctx)
+
+	webhook := destConfig.Webhook
+	if webhook == nil || webhook.URL == "" {
+		return fmt.Errorf("webhook url is required for Webhook destination")
+	}
+	if includePrivateKey(destConfig) {
+		// Never send the private key in the clear
+		if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" {
+			return fmt.Errorf("webhook url must use https unless includePrivateKey is false")
+		}
+	}
+
+	bundle, err := parseCertificateBundle(secret)
+	if err != nil {
+		return err
+	}
+	payload := webhookPayload{
+		Event:       "sync",
+		Namespace:   secret.Namespace,
+		Name:        secret.Name,
+		Fingerprint: certificateFingerprint(bundle.Leaf),
+		DNSNames:    bundle.Leaf.DNSNames,
+		NotAfter:    bundle.Leaf.NotAfter.UTC().Format(time.RFC3339),
+		Certificate: string(encodeCertificatesPEM(bundle.Chain()[:1])),
+		Chain:       string(encodeCertificatesPEM(bundle.CABundle())),
+		CA:          string(encodeCertificatesPEM(bundle.TrustAnchors())),
+	}
+	if includePrivateKey(destConfig) {
+		keyBytes, err := encodePrivateKey(ctx, p.Client, secret, destConfig.PrivateKey, nil)
+		if err != nil {
+			return err
+		}
+		payload.PrivateKey = string(keyBytes)
+	}
+
+	var body []byte
+	var contentType string
+	if webhook.Format == "Multipart" {
+		body, contentType, err = webhookMultipartBody(payload)
+	} else {
+		body, err = json.Marshal(payload)
+		contentType = "application/json"
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook body: %v", err)
+	}
+
+	logger.Info("Sending certificate to webhook", "url", webhook.URL)
+	resp, err := p.send(ctx, webhook, http.MethodPost, body, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if !webhookSucceeded(webhook, resp.StatusCode) {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// CheckExists sends a GET request to the webhook URL. A not found response
+// means the certificate does not exist.
+func (p *WebhookPlugin) CheckExists(ctx context.Context, destConfig certautov1.DestinationConfig) (bool, error) {
+	webhook := destConfig.Webhook
+	if webhook == nil || webhook.URL == "" {
+		return false, nil
+	}
+
+	resp, err := p.send(ctx, webhook, http.MethodGet, nil, "")
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case webhookSucceeded(webhook, resp.StatusCode):
+		return true, nil
+	default:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+}
+
+// Delete sends a DELETE request to the webhook URL.
+func (p *WebhookPlugin) Delete(ctx context.Context, destConfig certautov1.DestinationConfig) error {
+	return p.DeleteResource(ctx, destConfig, "")
+}
+
+// DeleteResource sends a DELETE request to the webhook URL. When the
+// namespace/name of the source secret is known, it is sent as a JSON body
+// with the event delete.
+func (p *WebhookPlugin) DeleteResource(ctx context.Context, destConfig certautov1.DestinationConfig, resourceName string) error {
+	webhook := destConfig.Webhook
+	if webhook == nil || webhook.URL == "" {
+		return nil
+	}
+
+	var body []byte
+	var contentType string
+	if namespace, name, ok := strings.Cut(resourceName, "/"); ok {
+		var err error
+		body, err = json.Marshal(webhookPayload{Event: "delete", Namespace: namespace, Name: name})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook body: %v", err)
+		}
+		contentType = "application/json"
+	}
+
+	log.FromContext(ctx).Info("Sending certificate deletion to webhook", "url", webhook.URL)
+	resp, err := p.send(ctx, webhook, http.MethodDelete, body, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound && !webhookSucceeded(webhook, resp.StatusCode) {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// send sends a signed request to the webhook URL.
+func (p *WebhookPlugin) send(ctx context.Context, webhook *certautov1.Webhook, method string, body []byte, contentType string) (*http.Response, error) {
+	httpClient, err := p.httpClient(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	for key, value := range webhook.Headers {
+		req.Header.Set(key, value)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if webhook.SigningSecretRef != nil {
+		key, err := readSecretKeyRef(ctx, p.Client, webhook.SigningSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook signing key: %v", err)
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, "sha256="+webhookSignature(key, timestamp, body))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send webhook request: %v", err)
+	}
+	return resp, nil
+}
+
+// httpClient returns an HTTP client with the configured CA bundle, client
+// certificate and timeout, which does not follow redirects.
+func (p *WebhookPlugin) httpClient(ctx context.Context, webhook *certautov1.Webhook) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if webhook.CABundleSecretRef != nil {
+		ca, err := readSecretKeyRef(ctx, p.Client, webhook.CABundleSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook CA bundle: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in webhook CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if ref := webhook.ClientCertificateSecretRef; ref != nil {
+		namespace, err := scopedNamespace(ctx, ref.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("webhook client certificate secret %s: %v", ref.Name, err)
+		}
+		clientSecret := &corev1.Secret{}
+		if err := p.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, clientSecret); err != nil {
+			return nil, fmt.Errorf("failed to get webhook client certificate secret %s/%s: %v", namespace, ref.Name, err)
+		}
+		cert, err := tls.X509KeyPair(clientSecret.Data[corev1.TLSCertKey], clientSecret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load webhook client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	timeout := defaultWebhookTimeout
+	if webhook.Timeout != nil {
+		timeout = webhook.Timeout.Duration
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// A redirect would resend the certificate, and possibly the private
+		// key, to a URL that was not configured
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return fmt.Errorf("webhook redirects to %s are not followed", req.URL.Redacted())
+		},
+	}, nil
+}
+
+// webhookMultipartBody encodes the payload as multipart/form-data, with the
+// metadata as fields and each PEM block as a file.
+func webhookMultipartBody(payload webhookPayload) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, field := range [][2]string{
+		{"event", payload.Event},
+		{"namespace", payload.Namespace},
+		{"name", payload.Name},
+		{"fingerprint", payload.Fingerprint},
+		{"notAfter", payload.NotAfter},
+	} {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, file := range [][3]string{
+		{"certificate", "tls.crt", payload.Certificate},
+		{"chain", "chain.pem", payload.Chain},
+		{"ca", "ca.crt", payload.CA},
+		{"privateKey", "tls.key", payload.PrivateKey},
+	} {
+		if file[2] == "" {
+			continue
+		}
+		part, err := writer.CreateFormFile(file[0], file[1])
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.WriteString(part, file[2]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// webhookSignature returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>".
+func webhookSignature(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookSucceeded reports whether the response code counts as success.
+func webhookSucceeded(webhook *certautov1.Webhook, statusCode int) bool {
+	if len(webhook.SuccessStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return slices.Contains(webhook.SuccessStatusCodes, int32(statusCode))
+}
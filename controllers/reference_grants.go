@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// referenceGranted reports whether a ReferenceGrant in the namespace of secret
+// allows objects of the given group and kind in namespace from to reference
+// the secret. Without the Gateway API installed nothing is granted.
+func referenceGranted(ctx context.Context, c client.Reader, group, kind, from string, secret types.NamespacedName) (bool, error) {
+	var grants gatewayv1beta1.ReferenceGrantList
+	if err := c.List(ctx, &grants, client.InNamespace(secret.Namespace)); err != nil {
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list referencegrants in %s: %v", secret.Namespace, err)
+	}
+
+	for _, grant := range grants.Items {
+		fromAllowed := slices.ContainsFunc(grant.Spec.From, func(f gatewayv1beta1.ReferenceGrantFrom) bool {
+			return string(f.Group) == group && string(f.Kind) == kind && string(f.Namespace) == from
+		})
+		toAllowed := slices.ContainsFunc(grant.Spec.To, func(t gatewayv1beta1.ReferenceGrantTo) bool {
+			return t.Group == "" && t.Kind == "Secret" && (t.Name == nil || *t.Name == "" || string(*t.Name) == secret.Name)
+		})
+		if fromAllowed && toAllowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+const (
+	// RestartedAtAnnotation is set on the pod template of restarted workloads,
+	// which makes their controllers roll out new pods.
+	RestartedAtAnnotation = "certauto.sanorg.in/restartedAt"
+
+	// RestartedForAnnotation records the fingerprint of the certificate a
+	// workload was restarted for, so that retries skip workloads that were
+	// already restarted.
+	RestartedForAnnotation = "certauto.sanorg.in/restartedFor"
+)
+
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
+
+// restartWorkloads triggers a rolling restart of the workloads selected by
+// targets for the certificate with the given fingerprint. The namespace
+// defaults to the first of namespaces and must be one of them. Missing
+// workloads and workloads already restarted for the certificate are skipped.
+func restartWorkloads(ctx context.Context, c client.Client, targets *certautov1.RestartTargets, fingerprint string, namespaces ...string) error {
+	logger := log.FromContext(ctx)
+
+	namespace := targets.Namespace
+	if namespace == "" {
+		namespace = namespaces[0]
+	}
+	if !slices.Contains(namespaces, namespace) {
+		return fmt.Errorf("restart targets in namespace %s are not allowed, use one of %s", namespace, strings.Join(namespaces, ", "))
+	}
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+
+	var workloads []client.Object
+	for _, ref := range targets.Workloads {
+		obj, err := newWorkload(ref.Kind)
+		if err != nil {
+			return err
+		}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, obj); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("Restart target not found, skipping", "kind", ref.Kind, "name", ref.Name, "namespace", namespace)
+				continue
+			}
+			return fmt.Errorf("failed to get %s %s: %v", ref.Kind, ref.Name, err)
+		}
+		workloads = append(workloads, obj)
+	}
+
+	if targets.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(targets.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid restart selector: %v", err)
+		}
+		opts := []client.ListOption{client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}}
+
+		var deployments appsv1.DeploymentList
+		if err := c.List(ctx, &deployments, opts...); err != nil {
+			return fmt.Errorf("failed to list deployments: %v", err)
+		}
+		for i := range deployments.Items {
+			workloads = append(workloads, &deployments.Items[i])
+		}
+		var statefulSets appsv1.StatefulSetList
+		if err := c.List(ctx, &statefulSets, opts...); err != nil {
+			return fmt.Errorf("failed to list statefulsets: %v", err)
+		}
+		for i := range statefulSets.Items {
+			workloads = append(workloads, &statefulSets.Items[i])
+		}
+		var daemonSets appsv1.DaemonSetList
+		if err := c.List(ctx, &daemonSets, opts...); err != nil {
+			return fmt.Errorf("failed to list daemonsets: %v", err)
+		}
+		for i := range daemonSets.Items {
+			workloads = append(workloads, &daemonSets.Items[i])
+		}
+	}
+
+	for _, obj := range workloads {
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		template := podTemplate(obj)
+		if template.Annotations[RestartedForAnnotation] == fingerprint {
+			logger.Info("Workload already restarted for this certificate, skipping", "name", obj.GetName(), "namespace", obj.GetNamespace())
+			continue
+		}
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[RestartedAtAnnotation] = restartedAt
+		template.Annotations[RestartedForAnnotation] = fingerprint
+
+		logger.Info("Restarting workload", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		if err := c.Patch(ctx, obj, patch); err != nil {
+			return fmt.Errorf("failed to restart %s: %v", obj.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// newWorkload returns an empty workload of the given kind.
+func newWorkload(kind string) (client.Object, error) {
+	switch kind {
+	case "Deployment":
+		return &appsv1.Deployment{}, nil
+	case "StatefulSet":
+		return &appsv1.StatefulSet{}, nil
+	case "DaemonSet":
+		return &appsv1.DaemonSet{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported restart target kind %s", kind)
+	}
+}
+
+// podTemplate returns the pod template of a workload returned by newWorkload.
+func podTemplate(obj client.Object) *corev1.PodTemplateSpec {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template
+	case *appsv1.DaemonSet:
+		return &w.Spec.Template
+	default:
+		return nil
+	}
+}
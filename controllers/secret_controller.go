@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	certautov1 "github.com/sanmarg/certauto/api/v1"
+)
+
+// SecretReconciler generates CertificateBindings for TLS secrets annotated with
+// certauto.sanorg.in/sync-to or certauto.sanorg.in/reflect-to.
+type SecretReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("secret", req.NamespacedName)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			// Generated bindings are garbage collected through their owner reference
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	secrets := []types.NamespacedName{req.NamespacedName}
+	if err := reconcileGeneratedBindings(ctrl.LoggerInto(ctx, log), r.Client, r.Scheme, &secret, secrets); err != nil {
+		log.Error(err, "Failed to reconcile generated certificatebindings")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isTLS := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		secret, ok := obj.(*corev1.Secret)
+		return ok && secret.Type == corev1.SecretTypeTLS
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}, builder.WithPredicates(isTLS)).
+		Owns(&certautov1.CertificateBinding{}).
+		Watches(
+			&certautov1.DestinationProvider{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &corev1.SecretList{}, SyncToAnnotation, obj.GetName())
+			}),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+				return annotatedObjects(ctx, r.Client, &corev1.SecretList{}, ReflectToAnnotation, obj.GetName())
+			}),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}